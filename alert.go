@@ -5,9 +5,12 @@ https://www.strangebee.com/thehive/
 package thehive5
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -84,6 +87,7 @@ type HiveAlertResponse struct {
 	Pap               int           `json:"pap"`
 	PapLabel          string        `json:"papLabel"`
 	Follow            bool          `json:"follow"`
+	Read              bool          `json:"read"`
 	CustomFields      []CustomField `json:"customFields"`
 	CaseTemplate      string        `json:"caseTemplate"`
 	ObservableCount   int64         `json:"observableCount"`
@@ -126,6 +130,7 @@ type shadowHiveAlertResponse struct {
 	Pap               int           `json:"pap"`
 	PapLabel          string        `json:"papLabel"`
 	Follow            bool          `json:"follow"`
+	Read              bool          `json:"read"`
 	CustomFields      []CustomField `json:"customFields"`
 	CaseTemplate      string        `json:"caseTemplate"`
 	ObservableCount   int64         `json:"observableCount"`
@@ -175,6 +180,7 @@ func (ar *HiveAlertResponse) UnmarshalJSON(data []byte) error {
 	ar.Pap = shadow.Pap
 	ar.PapLabel = shadow.PapLabel
 	ar.Follow = shadow.Follow
+	ar.Read = shadow.Read
 	ar.CustomFields = shadow.CustomFields
 	ar.CaseTemplate = shadow.CaseTemplate
 	ar.ObservableCount = shadow.ObservableCount
@@ -356,21 +362,59 @@ func (c *CustomField) MarshalJSON() ([]byte, error) {
 	}
 
 	// we try to convert for you
-	if c.Type == "string" {
-		c.Value = fmt.Sprintf("%s", c.Value)
+	value, err := customFieldValue(c.Type, c.Value)
+	if err != nil {
+		return nil, err
 	}
 
 	return json.Marshal(&struct {
-		Name  string `json:"name"`
-		Value string `json:"value"`
+		Name  string      `json:"name"`
+		Value interface{} `json:"value"`
 		*Alias
 	}{
 		Name:  strings.ToLower(c.Name),
-		Value: c.Value.(string),
+		Value: value,
 		Alias: (*Alias)(c),
 	})
 }
 
+// customFieldValue converts value into the format thehive5 expects for the field type
+// e.g. dates as milliseconds and integers read back from thehive5 as float64
+func customFieldValue(fieldType string, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch fieldType {
+	case "string", "url":
+		return fmt.Sprintf("%v", value), nil
+	case "date":
+		switch v := value.(type) {
+		case time.Time:
+			return v.UTC().UnixMilli(), nil
+		case *time.Time:
+			if v == nil {
+				return nil, nil
+			}
+			return v.UTC().UnixMilli(), nil
+		case float64:
+			return int64(v), nil
+		case int, int64:
+			return v, nil
+		}
+		return nil, fmt.Errorf("invalid date value for customfield: %v", value)
+	case "integer":
+		switch v := value.(type) {
+		case float64:
+			return int64(v), nil
+		case float32:
+			return int64(v), nil
+		}
+	}
+
+	return value, nil
+}
+
 type CustomFieldResponse struct {
 	Id          string              `json:"_id"`
 	Type        string              `json:"_type,omitempty"`
@@ -413,6 +457,13 @@ func (c *CustomFieldResponse) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	shadow.copyTo(c)
+
+	return nil
+}
+
+// copyTo sets the fields of c from the shadow, it's shared with CustomFieldDefinitionResponse
+func (shadow *shadowCustomFieldResponse) copyTo(c *CustomFieldResponse) {
 	c.Id = shadow.Id
 	c.Type = shadow.Type
 	c.CreatedBy = shadow.CreatedBy
@@ -427,17 +478,15 @@ func (c *CustomFieldResponse) UnmarshalJSON(data []byte) error {
 	c.Options = shadow.Options
 	c.Mandatory = shadow.Mandatory
 	c.ExtraData = shadow.ExtraData
-
-	return nil
 }
 
 // executeAlertSearchQuery is a helper function to do query related searches
-func (hive *Hivedata) executeAlertSearchQuery(query []byte) ([]HiveAlertResponse, error) {
+func (hive *Hivedata) executeAlertSearchQuery(ctx context.Context, query []byte) ([]HiveAlertResponse, error) {
 	url, err := url.JoinPath(hive.Url, "/api/v1/query")
 	if err != nil {
 		return nil, err
 	}
-	ret, err := hive.webRequest(url, POST, query)
+	ret, err := hive.webRequest(ctx, url, POST, query)
 
 	if err != nil {
 		return nil, err
@@ -450,68 +499,215 @@ func (hive *Hivedata) executeAlertSearchQuery(query []byte) ([]HiveAlertResponse
 
 // FindAlertsByFieldTimed allows a lookback for a specific time for the _UpdatedArt field and a specific field & value
 func (hive *Hivedata) FindAlertsByFieldTimed(queryfield string, queryvalue string, timeframe time.Time) ([]HiveAlertResponse, error) {
+	return hive.FindAlertsByFieldTimedContext(context.Background(), queryfield, queryvalue, timeframe)
+}
+
+// FindAlertsByFieldTimedContext is like FindAlertsByFieldTimed but uses ctx for the request
+func (hive *Hivedata) FindAlertsByFieldTimedContext(ctx context.Context, queryfield string, queryvalue string, timeframe time.Time) ([]HiveAlertResponse, error) {
 	// hive expects a milliseconds time string
 	time := strconv.FormatInt(timeframe.Unix()*1000, 10)
 
-	query, err := hive.createSearchQuery(
+	return NewQueryContext[HiveAlertResponse](ctx, hive,
 		SearchQuery{Name: "listAlert"},
 		SearchQuery{Name: "filter", Eq: &Filter{Field: strings.ToLower(queryfield), Value: &queryvalue}},
 		SearchQuery{Name: "filter", Gte: &Filter{Field: "_updatedAt", Value: &time}},
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	return hive.executeAlertSearchQuery(query)
+		SearchQuery{Name: "sort", Sort: &[1]map[string]string{{"_createdAt": "asc"}}},
+	).All()
 }
 
 // GetAlertsTimed returns all alerts which were updated since a specific date
 func (hive *Hivedata) GetAlertsTimed(timeframe time.Time) ([]HiveAlertResponse, error) {
+	return hive.GetAlertsTimedContext(context.Background(), timeframe)
+}
+
+// GetAlertsTimedContext is like GetAlertsTimed but uses ctx for the request
+func (hive *Hivedata) GetAlertsTimedContext(ctx context.Context, timeframe time.Time) ([]HiveAlertResponse, error) {
 	time := strconv.FormatInt(timeframe.Unix()*1000, 10)
 
-	query, err := hive.createSearchQuery(
+	return NewQueryContext[HiveAlertResponse](ctx, hive,
 		SearchQuery{Name: "listAlert"},
 		SearchQuery{Name: "filter", Gte: &Filter{Field: "_updatedAt", Value: &time}},
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	return hive.executeAlertSearchQuery(query)
+		SearchQuery{Name: "sort", Sort: &[1]map[string]string{{"_createdAt": "asc"}}},
+	).All()
 }
 
 // FindAlertsByCustomField does the same thing as FindAlertsByField but for custom fields.
 // Use this function for custom fields
 func (hive *Hivedata) FindAlertsByCustomField(queryfield string, queryvalue string) ([]HiveAlertResponse, error) {
+	return hive.FindAlertsByCustomFieldContext(context.Background(), queryfield, queryvalue)
+}
+
+// FindAlertsByCustomFieldContext is like FindAlertsByCustomField but uses ctx for the request
+func (hive *Hivedata) FindAlertsByCustomFieldContext(ctx context.Context, queryfield string, queryvalue string) ([]HiveAlertResponse, error) {
 
 	// Creates the json struct object
-	query, err := hive.createSearchQuery(
+	return NewQueryContext[HiveAlertResponse](ctx, hive,
 		SearchQuery{Name: "listAlert"},
 		SearchQuery{Name: "filter", Eq: &Filter{Field: fmt.Sprintf("customFields.%s", strings.ToLower(queryfield)), Value: &queryvalue}},
-	)
+		SearchQuery{Name: "sort", Sort: &[1]map[string]string{{"_createdAt": "asc"}}},
+	).All()
+}
 
+// FindAlerts allows to search for self defined alert queries
+func (hive *Hivedata) FindAlerts(searchQuery []SearchQuery) ([]HiveAlertResponse, error) {
+	return hive.FindAlertsContext(context.Background(), searchQuery)
+}
+
+// FindAlertsContext is like FindAlerts but uses ctx for the request
+func (hive *Hivedata) FindAlertsContext(ctx context.Context, searchQuery []SearchQuery) ([]HiveAlertResponse, error) {
+	query, err := hive.createSearchQuery(searchQuery...)
 	if err != nil {
 		return nil, err
 	}
 
-	return hive.executeAlertSearchQuery(query)
+	return hive.executeAlertSearchQuery(ctx, query)
+}
+
+// An AlertFilter contains the common criteria to search alerts. Empty fields are ignored
+type AlertFilter struct {
+	Type      string
+	Source    string
+	SourceRef string
+	// Status matches any of the given statuses
+	Status []string
+	// MinSeverity and MaxSeverity limit the severity range (inclusive)
+	MinSeverity Severity
+	MaxSeverity Severity
+	// AnyTags matches alerts with at least one of the tags
+	AnyTags []string
+	// AllTags matches alerts with all of the tags
+	AllTags  []string
+	Assignee string
+	// From (inclusive) and To (exclusive) limit DateField, which defaults to "date"
+	From      time.Time
+	To        time.Time
+	DateField string
+	// Imported matches alerts which were (or weren't) imported into a case
+	Imported *bool
+}
+
+// Expr converts the filter into an expression for the query builder
+func (f AlertFilter) Expr() Expr {
+	var exprs []Expr
+
+	fields := []struct{ name, value string }{
+		{"type", f.Type},
+		{"source", f.Source},
+		{"sourceRef", f.SourceRef},
+		{"assignee", f.Assignee},
+	}
+	for _, field := range fields {
+		if len(field.value) != 0 {
+			exprs = append(exprs, Eq(field.name, field.value))
+		}
+	}
+
+	if len(f.Status) != 0 {
+		exprs = append(exprs, In("status", toInterfaces(f.Status)...))
+	}
+	if f.MinSeverity != 0 {
+		exprs = append(exprs, Gte("severity", int(f.MinSeverity)))
+	}
+	if f.MaxSeverity != 0 {
+		exprs = append(exprs, Lte("severity", int(f.MaxSeverity)))
+	}
+	if len(f.AnyTags) != 0 {
+		exprs = append(exprs, In("tags", toInterfaces(f.AnyTags)...))
+	}
+	for _, tag := range f.AllTags {
+		exprs = append(exprs, Eq("tags", tag))
+	}
+
+	dateField := f.DateField
+	if len(dateField) == 0 {
+		dateField = "date"
+	}
+	if !f.From.IsZero() {
+		exprs = append(exprs, Gte(dateField, f.From))
+	}
+	if !f.To.IsZero() {
+		exprs = append(exprs, Lt(dateField, f.To))
+	}
+
+	if f.Imported != nil {
+		exprs = append(exprs, Eq("imported", *f.Imported))
+	}
+
+	switch len(exprs) {
+	case 0:
+		return Expr{}
+	case 1:
+		return exprs[0]
+	}
+	return And(exprs...)
+}
+
+// Query starts a query builder on all alerts matching the filter
+// Use SortBy and Page on the result for a single page
+func (f AlertFilter) Query() *QueryBuilder {
+	b := Alerts()
+	if expr := f.Expr(); !expr.IsZero() {
+		b.Where(expr)
+	}
+	return b
+}
+
+// FindAlertsByFilter returns all alerts matching the filter, newest first
+// It pages through the results, use FindAlerts with AlertFilter.Query().Page() for a single page
+func (hive *Hivedata) FindAlertsByFilter(filter AlertFilter) ([]HiveAlertResponse, error) {
+	return hive.FindAlertsByFilterContext(context.Background(), filter)
+}
+
+// FindAlertsByFilterContext is like FindAlertsByFilter but uses ctx for the requests
+func (hive *Hivedata) FindAlertsByFilterContext(ctx context.Context, filter AlertFilter) ([]HiveAlertResponse, error) {
+	return NewQueryContext[HiveAlertResponse](ctx, hive, filter.Query().SortBy("date", Desc).SortBy("_id", Asc).Build()...).All()
+}
+
+// toInterfaces converts values for In
+func toInterfaces(values []string) []interface{} {
+	ret := make([]interface{}, len(values))
+	for i, v := range values {
+		ret[i] = v
+	}
+	return ret
+}
+
+// CountAlerts returns the number of alerts matching the filter stages
+// Without filters all alerts are counted
+func (hive *Hivedata) CountAlerts(filters ...SearchQuery) (int, error) {
+	return hive.CountAlertsContext(context.Background(), filters...)
+}
+
+// CountAlertsContext is like CountAlerts but uses ctx for the request
+func (hive *Hivedata) CountAlertsContext(ctx context.Context, filters ...SearchQuery) (int, error) {
+	return hive.CountContext(ctx, append([]SearchQuery{{Name: "listAlert"}}, filters...))
 }
 
 // MergeAlert merges an alert into a case.
 // The alertId must be a string, while the caseNumber must be an int.
 // It returns an error if the merging process fails.
 func (hive *Hivedata) MergeAlert(alertId string, caseNumber int) error {
+	return hive.MergeAlertContext(context.Background(), alertId, caseNumber)
+}
+
+// MergeAlertContext is like MergeAlert but uses ctx for the request
+func (hive *Hivedata) MergeAlertContext(ctx context.Context, alertId string, caseNumber int) error {
 	url, err := url.JoinPath(hive.Url, "/api/v1/alert/", alertId, "/merge/", strconv.Itoa(caseNumber))
 	if err != nil {
 		return err
 	}
 
-	_, err = hive.webRequest(url, POST, nil)
+	_, err = hive.webRequest(ctx, url, POST, nil)
 	return err
 }
 
 // CreateAlert adds a new alert on thehive5 and returns the created alert response.
 func (hive *Hivedata) CreateAlert(alertObject *HiveAlert) (*HiveAlertResponse, error) {
+	return hive.CreateAlertContext(context.Background(), alertObject)
+}
+
+// CreateAlertContext is like CreateAlert but uses ctx for the request
+func (hive *Hivedata) CreateAlertContext(ctx context.Context, alertObject *HiveAlert) (*HiveAlertResponse, error) {
 	url, err := url.JoinPath(hive.Url, "api/v1/alert")
 	if err != nil {
 		return nil, err
@@ -521,7 +717,7 @@ func (hive *Hivedata) CreateAlert(alertObject *HiveAlert) (*HiveAlertResponse, e
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, POST, jsondata)
+	ret, err := hive.webRequest(ctx, url, POST, jsondata)
 	if err != nil {
 		return nil, err
 	}
@@ -537,23 +733,33 @@ func (hive *Hivedata) CreateAlert(alertObject *HiveAlert) (*HiveAlertResponse, e
 
 // DeleteAlert deletes an alert and returns an error if the deletion fails.
 func (hive *Hivedata) DeleteAlert(alertId string) error {
+	return hive.DeleteAlertContext(context.Background(), alertId)
+}
+
+// DeleteAlertContext is like DeleteAlert but uses ctx for the request
+func (hive *Hivedata) DeleteAlertContext(ctx context.Context, alertId string) error {
 	url, err := url.JoinPath(hive.Url, "api/v1/alert", alertId)
 	if err != nil {
 		return err
 	}
-	_, err = hive.webRequest(url, DELETE, nil)
+	_, err = hive.webRequest(ctx, url, DELETE, nil)
 	return err
 }
 
 // GetAlert retrieves a single Alert using its alertId.
 // It returns an HiveAlertResponse or an error.
 func (hive *Hivedata) GetAlert(alertId string) (*HiveAlertResponse, error) {
+	return hive.GetAlertContext(context.Background(), alertId)
+}
+
+// GetAlertContext is like GetAlert but uses ctx for the request
+func (hive *Hivedata) GetAlertContext(ctx context.Context, alertId string) (*HiveAlertResponse, error) {
 	url, err := url.JoinPath(hive.Url, "api/v1/alert", alertId)
 	if err != nil {
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, GET, nil)
+	ret, err := hive.webRequest(ctx, url, GET, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -565,6 +771,11 @@ func (hive *Hivedata) GetAlert(alertId string) (*HiveAlertResponse, error) {
 
 // UpdateAlert updates an alert given a HiveUpdateAlert struct
 func (hive *Hivedata) UpdateAlert(alertId string, alert *HiveUpdateAlert) error {
+	return hive.UpdateAlertContext(context.Background(), alertId, alert)
+}
+
+// UpdateAlertContext is like UpdateAlert but uses ctx for the request
+func (hive *Hivedata) UpdateAlertContext(ctx context.Context, alertId string, alert *HiveUpdateAlert) error {
 	url, err := url.JoinPath(hive.Url, "api/v1/alert", alertId)
 	if err != nil {
 		return err
@@ -574,29 +785,39 @@ func (hive *Hivedata) UpdateAlert(alertId string, alert *HiveUpdateAlert) error
 	if err != nil {
 		return err
 	}
-	_, err = hive.webRequest(url, PATCH, jsondata)
+	_, err = hive.webRequest(ctx, url, PATCH, jsondata)
 	return err
 }
 
 // AddAlertObservable adds a new observable to an existing alert.
 // Returns an error if the addition fails.
 func (hive *Hivedata) AddAlertObservable(alertNumber string, observable Observable) error {
+	return hive.AddAlertObservableContext(context.Background(), alertNumber, observable)
+}
+
+// AddAlertObservableContext is like AddAlertObservable but uses ctx for the request
+func (hive *Hivedata) AddAlertObservableContext(ctx context.Context, alertNumber string, observable Observable) error {
 	url, err := url.JoinPath(hive.Url, "api/v1/alert", alertNumber, "observable")
 	if err != nil {
 		return err
 	}
 
-	jsondata, err := json.Marshal(observable)
+	jsondata, err := json.Marshal(&observable)
 	if err != nil {
 		return err
 	}
 
-	_, err = hive.webRequest(url, POST, jsondata)
+	_, err = hive.webRequest(ctx, url, POST, jsondata)
 	return err
 }
 
 // GetAlertObservables returns all observables associated with an alert
 func (hive *Hivedata) GetAlertObservables(alertId string) ([]ObservableResponse, error) {
+	return hive.GetAlertObservablesContext(context.Background(), alertId)
+}
+
+// GetAlertObservablesContext is like GetAlertObservables but uses ctx for the request
+func (hive *Hivedata) GetAlertObservablesContext(ctx context.Context, alertId string) ([]ObservableResponse, error) {
 	query, err := hive.createSearchQuery(
 		SearchQuery{Name: "getAlert", IdOrName: alertId},
 		SearchQuery{Name: "observables"},
@@ -606,13 +827,18 @@ func (hive *Hivedata) GetAlertObservables(alertId string) ([]ObservableResponse,
 		return nil, err
 	}
 
-	return hive.executeObservableSearchQuery(query)
+	return hive.executeObservableSearchQuery(ctx, query)
 }
 
 // GetAlertObservable returns a single observable associated with an alert.
 // Use this if you need to get all alerts that have a specific observable with a specific value
 // Example: queryfield: data, queryvalue: 127.0.0.1
 func (hive *Hivedata) GetAlertObservable(alertId, queryfield, queryvalue string) (*ObservableResponse, error) {
+	return hive.GetAlertObservableContext(context.Background(), alertId, queryfield, queryvalue)
+}
+
+// GetAlertObservableContext is like GetAlertObservable but uses ctx for the request
+func (hive *Hivedata) GetAlertObservableContext(ctx context.Context, alertId, queryfield, queryvalue string) (*ObservableResponse, error) {
 
 	query, err := hive.createSearchQuery(
 		SearchQuery{Name: "getAlert", IdOrName: alertId},
@@ -624,7 +850,7 @@ func (hive *Hivedata) GetAlertObservable(alertId, queryfield, queryvalue string)
 		return nil, err
 	}
 
-	resp, err := hive.executeObservableSearchQuery(query)
+	resp, err := hive.executeObservableSearchQuery(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -635,3 +861,276 @@ func (hive *Hivedata) GetAlertObservable(alertId, queryfield, queryvalue string)
 
 	return nil, fmt.Errorf("no observable found")
 }
+
+// An UpsertResult describes which path UpsertAlert took
+type UpsertResult int
+
+// Constants to handle the result of UpsertAlert
+const (
+	AlertCreated UpsertResult = iota + 1
+	AlertUpdated
+	AlertUnchanged
+)
+
+func (u UpsertResult) String() string {
+	switch u {
+	case AlertCreated:
+		return "created"
+	case AlertUpdated:
+		return "updated"
+	case AlertUnchanged:
+		return "unchanged"
+	}
+	return "unknown"
+}
+
+// An AlertUpsert contains the outcome of UpsertAlert
+type AlertUpsert struct {
+	Result UpsertResult
+	Alert  *HiveAlertResponse
+	// AddedTags and AddedObservables are only set on updates
+	AddedTags        []string
+	AddedObservables []Observable
+}
+
+// isDuplicateAlert reports if CreateAlert failed because an alert with the same type, source and sourceRef exists
+// thehive5 rejects those with a CreateError instead of a conflict
+func isDuplicateAlert(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return IsConflict(err) || (apiErr.Type() == "CreateError" && strings.Contains(apiErr.Response.Message, "already exists"))
+}
+
+// UpsertAlert creates an alert or merges it into the existing alert with the same type, source and sourceRef
+// New tags and observables are added and a changed description is updated. Other attributes of an existing alert are kept
+func (hive *Hivedata) UpsertAlert(alert *HiveAlert) (*AlertUpsert, error) {
+	return hive.UpsertAlertContext(context.Background(), alert)
+}
+
+// UpsertAlertContext is like UpsertAlert but uses ctx for the requests
+func (hive *Hivedata) UpsertAlertContext(ctx context.Context, alert *HiveAlert) (*AlertUpsert, error) {
+	alertType := alert.Type
+	if len(alertType) == 0 {
+		alertType = "alert"
+	}
+	filter := AlertFilter{Type: alertType, Source: alert.Source, SourceRef: alert.SourceRef}
+
+	existing, err := hive.findAlert(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		created, err := hive.CreateAlertContext(ctx, alert)
+		if err == nil {
+			return &AlertUpsert{Result: AlertCreated, Alert: created}, nil
+		}
+		if !isDuplicateAlert(err) {
+			return nil, err
+		}
+
+		// created concurrently since the lookup
+		existing, err = hive.findAlert(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			return nil, fmt.Errorf("alert %s/%s/%s exists but can't be found", alertType, alert.Source, alert.SourceRef)
+		}
+	}
+
+	ret := &AlertUpsert{Result: AlertUnchanged, Alert: existing}
+
+	for _, tag := range alert.Tags {
+		if !slices.Contains(existing.Tags, tag) && !slices.Contains(ret.AddedTags, tag) {
+			ret.AddedTags = append(ret.AddedTags, tag)
+		}
+	}
+
+	update := &HiveUpdateAlert{Type: existing.AlertType, AddTags: ret.AddedTags}
+	if len(alert.Description) != 0 && alert.Description != existing.Description {
+		update.Description = alert.Description
+	}
+
+	if len(update.AddTags) != 0 || len(update.Description) != 0 {
+		if err := hive.UpdateAlertContext(ctx, existing.Id, update); err != nil {
+			return nil, err
+		}
+		ret.Result = AlertUpdated
+	}
+
+	if alert.Observables != nil && len(*alert.Observables) != 0 {
+		observables, err := hive.GetAlertObservablesContext(ctx, existing.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, observable := range *alert.Observables {
+			if observableExists(observables, observable) {
+				continue
+			}
+			if err := hive.AddAlertObservableContext(ctx, existing.Id, observable); err != nil {
+				return nil, err
+			}
+			ret.AddedObservables = append(ret.AddedObservables, observable)
+			ret.Result = AlertUpdated
+		}
+	}
+
+	if ret.Result == AlertUpdated {
+		ret.Alert, err = hive.GetAlertContext(ctx, existing.Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+// findAlert returns the first alert matching filter or nil
+func (hive *Hivedata) findAlert(ctx context.Context, filter AlertFilter) (*HiveAlertResponse, error) {
+	alerts, err := hive.FindAlertsContext(ctx, filter.Query().Page(0, 1).Build())
+	if err != nil {
+		return nil, err
+	}
+	if len(alerts) == 0 {
+		return nil, nil
+	}
+	return &alerts[0], nil
+}
+
+// observableExists compares dataType and data as thehive5 does for duplicate observables
+func observableExists(observables []ObservableResponse, observable Observable) bool {
+	for _, existing := range observables {
+		if existing.DataType == observable.DataType && existing.Data == observable.Data {
+			return true
+		}
+	}
+	return false
+}
+
+// Stages of the alert statuses. Every alert status belongs to one of them
+const (
+	AlertStageNew        = "New"
+	AlertStageInProgress = "InProgress"
+	AlertStageClosed     = "Closed"
+	AlertStageImported   = "Imported"
+)
+
+// An AlertStatusResponse contains an alert status option. It has the same attributes as a case status
+type AlertStatusResponse = CaseStatusResponse
+
+// GetAlertStatusOptions returns all possible alert statuses (New/InProgress/Ignored etc.) with their stage
+func (hive *Hivedata) GetAlertStatusOptions() ([]AlertStatusResponse, error) {
+	return hive.GetAlertStatusOptionsContext(context.Background())
+}
+
+// GetAlertStatusOptionsContext is like GetAlertStatusOptions but uses ctx for the request
+func (hive *Hivedata) GetAlertStatusOptionsContext(ctx context.Context) ([]AlertStatusResponse, error) {
+	query, err := hive.createSearchQuery(
+		SearchQuery{Name: "listAlertStatus"},
+		SearchQuery{Name: "sort", Sort: &[1]map[string]string{{"order": "asc"}}},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return hive.executeCaseStatusQuery(ctx, query)
+}
+
+// SetAlertStatus changes the status of an alert after checking it against GetAlertStatusOptions
+// Statuses of the Imported stage are refused as they are set by importing or merging the alert into a case
+func (hive *Hivedata) SetAlertStatus(alertId string, status string) error {
+	return hive.SetAlertStatusContext(context.Background(), alertId, status)
+}
+
+// SetAlertStatusContext is like SetAlertStatus but uses ctx for the requests
+func (hive *Hivedata) SetAlertStatusContext(ctx context.Context, alertId string, status string) error {
+	options, err := hive.GetAlertStatusOptionsContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var valid []string
+	for _, option := range options {
+		if option.Stage == AlertStageImported {
+			continue
+		}
+		if option.Value == status {
+			return hive.patchAlert(ctx, alertId, map[string]interface{}{"status": status})
+		}
+		valid = append(valid, option.Value)
+	}
+
+	return fmt.Errorf("invalid alert status %q, valid statuses are %s", status, strings.Join(valid, ", "))
+}
+
+// FollowAlert enables the notifications of an alert on updates from its source
+func (hive *Hivedata) FollowAlert(alertId string) error {
+	return hive.FollowAlertContext(context.Background(), alertId)
+}
+
+// FollowAlertContext is like FollowAlert but uses ctx for the request
+func (hive *Hivedata) FollowAlertContext(ctx context.Context, alertId string) error {
+	return hive.alertAction(ctx, alertId, "follow")
+}
+
+// UnfollowAlert ignores further updates of an alert from its source
+func (hive *Hivedata) UnfollowAlert(alertId string) error {
+	return hive.UnfollowAlertContext(context.Background(), alertId)
+}
+
+// UnfollowAlertContext is like UnfollowAlert but uses ctx for the request
+func (hive *Hivedata) UnfollowAlertContext(ctx context.Context, alertId string) error {
+	return hive.alertAction(ctx, alertId, "unfollow")
+}
+
+// MarkAlertAsRead marks an alert as read
+func (hive *Hivedata) MarkAlertAsRead(alertId string) error {
+	return hive.MarkAlertAsReadContext(context.Background(), alertId)
+}
+
+// MarkAlertAsReadContext is like MarkAlertAsRead but uses ctx for the request
+func (hive *Hivedata) MarkAlertAsReadContext(ctx context.Context, alertId string) error {
+	return hive.alertAction(ctx, alertId, "markAsRead")
+}
+
+// MarkAlertAsUnread marks an alert as unread
+func (hive *Hivedata) MarkAlertAsUnread(alertId string) error {
+	return hive.MarkAlertAsUnreadContext(context.Background(), alertId)
+}
+
+// MarkAlertAsUnreadContext is like MarkAlertAsUnread but uses ctx for the request
+func (hive *Hivedata) MarkAlertAsUnreadContext(ctx context.Context, alertId string) error {
+	return hive.alertAction(ctx, alertId, "markAsUnread")
+}
+
+// alertAction posts to one of the action endpoints of an alert, e.g. /api/v1/alert/{id}/follow
+func (hive *Hivedata) alertAction(ctx context.Context, alertId string, action string) error {
+	url, err := url.JoinPath(hive.Url, "/api/v1/alert", alertId, action)
+	if err != nil {
+		return err
+	}
+
+	_, err = hive.webRequest(ctx, url, POST, nil)
+	return err
+}
+
+// patchAlert updates single attributes of an alert
+// Unlike UpdateAlert it doesn't send a type, which would overwrite the type of the alert
+func (hive *Hivedata) patchAlert(ctx context.Context, alertId string, fields map[string]interface{}) error {
+	url, err := url.JoinPath(hive.Url, "/api/v1/alert", alertId)
+	if err != nil {
+		return err
+	}
+
+	jsondata, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	_, err = hive.webRequest(ctx, url, PATCH, jsondata)
+	return err
+}
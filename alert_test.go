@@ -0,0 +1,351 @@
+package thehive5_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	thehive5 "github.com/b401/goHive5"
+	"github.com/b401/goHive5/thehive5test"
+)
+
+func TestAlertFilterExpr(t *testing.T) {
+	from := time.UnixMilli(1700000000000)
+	to := from.Add(24 * time.Hour)
+	imported := false
+
+	tests := []struct {
+		name   string
+		filter thehive5.AlertFilter
+		want   string
+	}{
+		{"empty", thehive5.AlertFilter{}, `{}`},
+		{"status", thehive5.AlertFilter{Status: []string{"New", "InProgress"}},
+			`{"_in":{"_field":"status","_values":["New","InProgress"]}}`},
+		{"severity range", thehive5.AlertFilter{MinSeverity: thehive5.SeverityMedium, MaxSeverity: thehive5.SeverityHigh},
+			`{"_and":[{"_gte":{"_field":"severity","_value":2}},{"_lte":{"_field":"severity","_value":3}}]}`},
+		{"min severity", thehive5.AlertFilter{MinSeverity: thehive5.SeverityHigh}, `{"_gte":{"_field":"severity","_value":3}}`},
+		{"any tags", thehive5.AlertFilter{AnyTags: []string{"phishing", "spam"}},
+			`{"_in":{"_field":"tags","_values":["phishing","spam"]}}`},
+		{"all tags", thehive5.AlertFilter{AllTags: []string{"phishing", "spam"}},
+			`{"_and":[{"_eq":{"_field":"tags","_value":"phishing"}},{"_eq":{"_field":"tags","_value":"spam"}}]}`},
+		{"date range", thehive5.AlertFilter{From: from, To: to},
+			`{"_and":[{"_gte":{"_field":"date","_value":1700000000000}},{"_lt":{"_field":"date","_value":1700086400000}}]}`},
+		{"date field", thehive5.AlertFilter{From: from, DateField: "_createdAt"}, `{"_gte":{"_field":"_createdAt","_value":1700000000000}}`},
+		{"not imported", thehive5.AlertFilter{Imported: &imported}, `{"_eq":{"_field":"imported","_value":false}}`},
+		{"all criteria", thehive5.AlertFilter{
+			Type: "external", Source: "siem", SourceRef: "42", Assignee: "analyst",
+			Status: []string{"New"}, MinSeverity: thehive5.SeverityLow, MaxSeverity: thehive5.SeverityCritical,
+			AnyTags: []string{"phishing"}, AllTags: []string{"mail"}, From: from, To: to, Imported: &imported,
+		}, `{"_and":[` +
+			`{"_eq":{"_field":"type","_value":"external"}},` +
+			`{"_eq":{"_field":"source","_value":"siem"}},` +
+			`{"_eq":{"_field":"sourceRef","_value":"42"}},` +
+			`{"_eq":{"_field":"assignee","_value":"analyst"}},` +
+			`{"_in":{"_field":"status","_values":["New"]}},` +
+			`{"_gte":{"_field":"severity","_value":1}},` +
+			`{"_lte":{"_field":"severity","_value":4}},` +
+			`{"_in":{"_field":"tags","_values":["phishing"]}},` +
+			`{"_eq":{"_field":"tags","_value":"mail"}},` +
+			`{"_gte":{"_field":"date","_value":1700000000000}},` +
+			`{"_lt":{"_field":"date","_value":1700086400000}},` +
+			`{"_eq":{"_field":"imported","_value":false}}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.filter.Expr())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+
+	stages, err := json.Marshal(thehive5.AlertFilter{}.Query().Build())
+	if err != nil || string(stages) != `[{"_name":"listAlert"}]` {
+		t.Fatalf("Query of an empty filter = %s, %v", stages, err)
+	}
+}
+
+func TestFindAlertsByFilter(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	hive := srv.Hive()
+
+	base := time.UnixMilli(1700000000000)
+	severities := []string{"low", "medium", "high", "critical"}
+	tags := []string{"spam", "phishing", "malware"}
+	filter := thehive5.AlertFilter{
+		Source:      "siem",
+		Status:      []string{"New", "InProgress"},
+		MinSeverity: thehive5.SeverityMedium,
+		MaxSeverity: thehive5.SeverityCritical,
+		AnyTags:     []string{"phishing", "spam"},
+		From:        base.Add(10 * time.Minute),
+		To:          base.Add(290 * time.Minute),
+	}
+
+	var want []string
+	for i := 0; i < 300; i++ {
+		status := "New"
+		if i%5 == 0 {
+			status = "Ignored"
+		}
+		alert := thehive5.HiveAlert{
+			Type: "external", Source: "siem", SourceRef: fmt.Sprint(i), Title: "alert", Description: "alert",
+			Date: base.Add(time.Duration(i) * time.Minute), Severity: severities[i%4], Tags: []string{tags[i%3]}, Status: status,
+		}
+		if _, err := hive.CreateAlert(&alert); err != nil {
+			t.Fatalf("CreateAlert: %v", err)
+		}
+		if i >= 10 && i < 290 && status == "New" && i%4 != 0 && i%3 != 2 {
+			want = append(want, alert.SourceRef)
+		}
+	}
+	if len(want) <= thehive5.DefaultPageSize {
+		t.Fatalf("%d matching alerts fit on a single page", len(want))
+	}
+
+	srv.ClearRequests()
+	alerts, err := hive.FindAlertsByFilter(filter)
+	if err != nil {
+		t.Fatalf("FindAlertsByFilter: %v", err)
+	}
+	if n := queries(srv); n != 2 {
+		t.Fatalf("FindAlertsByFilter sent %d queries, want 2", n)
+	}
+
+	// newest first
+	for i, j := 0, len(want)-1; i < j; i, j = i+1, j-1 {
+		want[i], want[j] = want[j], want[i]
+	}
+	var got []string
+	for _, alert := range alerts {
+		got = append(got, alert.SourceRef)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("FindAlertsByFilter returned %d alerts %v\nwant %d alerts %v", len(got), got, len(want), want)
+	}
+
+	// a single page of the same filter
+	page, err := hive.FindAlerts(filter.Query().SortBy("date", thehive5.Asc).Page(0, 5).Build())
+	if err != nil {
+		t.Fatalf("FindAlerts: %v", err)
+	}
+	got = nil
+	for _, alert := range page {
+		got = append(got, alert.SourceRef)
+	}
+	var oldest []string
+	for i := len(want) - 1; i >= len(want)-5; i-- {
+		oldest = append(oldest, want[i])
+	}
+	if fmt.Sprint(got) != fmt.Sprint(oldest) {
+		t.Fatalf("FindAlerts = %v, want %v", got, oldest)
+	}
+}
+
+func TestUpsertAlert(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	hive := srv.Hive()
+
+	observables := []thehive5.Observable{{DataType: "ip", Data: "10.0.0.1"}}
+	alert := thehive5.HiveAlert{Type: "external", Source: "siem", SourceRef: "42", Title: "alert", Description: "first", Tags: []string{"phishing"}, Observables: &observables}
+
+	created, err := hive.UpsertAlert(&alert)
+	if err != nil {
+		t.Fatalf("UpsertAlert: %v", err)
+	}
+	if created.Result != thehive5.AlertCreated || created.Alert.SourceRef != "42" {
+		t.Fatalf("first UpsertAlert = %v %+v", created.Result, created.Alert)
+	}
+
+	// the same alert again changes nothing
+	srv.ClearRequests()
+	unchanged, err := hive.UpsertAlert(&alert)
+	if err != nil {
+		t.Fatalf("UpsertAlert: %v", err)
+	}
+	if unchanged.Result != thehive5.AlertUnchanged || unchanged.Alert.Id != created.Alert.Id || len(unchanged.AddedTags) != 0 || len(unchanged.AddedObservables) != 0 {
+		t.Fatalf("repeated UpsertAlert = %+v", unchanged)
+	}
+	for _, req := range srv.Requests() {
+		if req.Method != http.MethodPost || req.Path != "/api/v1/query" {
+			t.Fatalf("repeated UpsertAlert sent %s %s", req.Method, req.Path)
+		}
+	}
+
+	// new tags, a new description and new observables are merged into the existing alert
+	observables = append(observables, thehive5.Observable{DataType: "domain", Data: "evil.example"})
+	alert.Tags = []string{"phishing", "mail"}
+	alert.Description = "second"
+	updated, err := hive.UpsertAlert(&alert)
+	if err != nil {
+		t.Fatalf("UpsertAlert: %v", err)
+	}
+	if updated.Result != thehive5.AlertUpdated || updated.Alert.Id != created.Alert.Id {
+		t.Fatalf("changed UpsertAlert = %+v", updated)
+	}
+	if fmt.Sprint(updated.AddedTags) != "[mail]" || len(updated.AddedObservables) != 1 || updated.AddedObservables[0].Data != "evil.example" {
+		t.Fatalf("changed UpsertAlert added tags %v and observables %v", updated.AddedTags, updated.AddedObservables)
+	}
+	if updated.Alert.Description != "second" || len(updated.Alert.Tags) != 2 {
+		t.Fatalf("updated alert %+v", updated.Alert)
+	}
+	if alerts := srv.Objects(thehive5test.KindAlert); len(alerts) != 1 {
+		t.Fatalf("%d alerts stored, want 1", len(alerts))
+	}
+	if got := srv.Objects(thehive5test.KindObservable); len(got) != 2 {
+		t.Fatalf("%d observables stored, want 2", len(got))
+	}
+}
+
+func TestUpsertAlertCreatedConcurrently(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	hive := srv.Hive()
+	other := srv.Hive()
+
+	// another integration creates the alert between the lookup and the creation
+	var raced atomic.Bool
+	srv.Intercept = func(req thehive5test.Request) error {
+		if req.Method == http.MethodPost && req.Path == "/api/v1/alert" && raced.CompareAndSwap(false, true) {
+			if _, err := other.CreateAlert(&thehive5.HiveAlert{Type: "external", Source: "siem", SourceRef: "42", Title: "alert", Description: "alert"}); err != nil {
+				t.Errorf("concurrent CreateAlert: %v", err)
+			}
+		}
+		return nil
+	}
+
+	result, err := hive.UpsertAlert(&thehive5.HiveAlert{Type: "external", Source: "siem", SourceRef: "42", Title: "alert", Description: "alert", Tags: []string{"phishing"}})
+	if err != nil {
+		t.Fatalf("UpsertAlert: %v", err)
+	}
+	if result.Result != thehive5.AlertUpdated || fmt.Sprint(result.AddedTags) != "[phishing]" {
+		t.Fatalf("UpsertAlert = %+v, want the concurrently created alert to be updated", result)
+	}
+	if alerts := srv.Objects(thehive5test.KindAlert); len(alerts) != 1 {
+		t.Fatalf("%d alerts stored, want 1", len(alerts))
+	}
+
+	// other errors of the creation aren't treated as duplicates
+	srv.Intercept = func(req thehive5test.Request) error {
+		if req.Method == http.MethodPost && req.Path == "/api/v1/alert" {
+			return thehive5test.Error(http.StatusBadRequest, "BadRequestError", "Invalid severity")
+		}
+		return nil
+	}
+	if _, err := hive.UpsertAlert(&thehive5.HiveAlert{Type: "external", Source: "siem", SourceRef: "43", Title: "alert", Description: "alert"}); !thehive5.IsBadRequest(err) {
+		t.Fatalf("UpsertAlert with a rejected alert = %v", err)
+	}
+}
+
+func TestAlertStatus(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	hive := srv.Hive()
+
+	// an instance with a custom status, listed out of order
+	srv.AlertStatuses = []map[string]interface{}{
+		{"value": "Ignored", "stage": "Closed", "order": 5},
+		{"value": "New", "stage": "New", "order": 1},
+		{"value": "Escalated", "stage": "InProgress", "order": 3},
+		{"value": "Imported", "stage": "Imported", "order": 4},
+		{"value": "InProgress", "stage": "InProgress", "order": 2},
+	}
+
+	options, err := hive.GetAlertStatusOptions()
+	if err != nil {
+		t.Fatalf("GetAlertStatusOptions: %v", err)
+	}
+	var values []string
+	for _, option := range options {
+		values = append(values, option.Value+"/"+option.Stage)
+	}
+	if want := "New/New InProgress/InProgress Escalated/InProgress Imported/Imported Ignored/Closed"; strings.Join(values, " ") != want {
+		t.Fatalf("GetAlertStatusOptions = %v, want the order %s", values, want)
+	}
+
+	alert, err := hive.CreateAlert(&thehive5.HiveAlert{Type: "external", Source: "test", SourceRef: "1", Title: "alert", Description: "alert"})
+	if err != nil {
+		t.Fatalf("CreateAlert: %v", err)
+	}
+	if err := hive.SetAlertStatus(alert.Id, "Escalated"); err != nil {
+		t.Fatalf("SetAlertStatus: %v", err)
+	}
+	got, err := hive.GetAlert(alert.Id)
+	if err != nil {
+		t.Fatalf("GetAlert: %v", err)
+	}
+	if got.Status != "Escalated" || got.Stage != "InProgress" || got.AlertType != "external" {
+		t.Fatalf("alert after SetAlertStatus: status %s stage %s type %s", got.Status, got.Stage, got.AlertType)
+	}
+
+	// statuses of the Imported stage and unknown statuses are refused without a PATCH
+	srv.ClearRequests()
+	for _, status := range []string{"Imported", "Closed", "escalated"} {
+		err := hive.SetAlertStatus(alert.Id, status)
+		if err == nil || !strings.Contains(err.Error(), "valid statuses are New, InProgress, Escalated, Ignored") {
+			t.Fatalf("SetAlertStatus(%s) = %v", status, err)
+		}
+	}
+	for _, req := range srv.Requests() {
+		if req.Method == http.MethodPatch {
+			t.Fatalf("refused status sent %s %s", req.Method, req.Path)
+		}
+	}
+}
+
+func TestAlertFollowAndRead(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	hive := srv.Hive()
+
+	alert, err := hive.CreateAlert(&thehive5.HiveAlert{Type: "external", Source: "test", SourceRef: "1", Title: "alert", Description: "alert"})
+	if err != nil {
+		t.Fatalf("CreateAlert: %v", err)
+	}
+	if !alert.Follow || alert.Read {
+		t.Fatalf("new alert follow %v read %v", alert.Follow, alert.Read)
+	}
+
+	steps := []struct {
+		action func(string) error
+		path   string
+		follow bool
+		read   bool
+	}{
+		{hive.UnfollowAlert, "unfollow", false, false},
+		{hive.MarkAlertAsRead, "markAsRead", false, true},
+		{hive.FollowAlert, "follow", true, true},
+		{hive.MarkAlertAsUnread, "markAsUnread", true, false},
+	}
+	for _, step := range steps {
+		srv.ClearRequests()
+		if err := step.action(alert.Id); err != nil {
+			t.Fatalf("%s: %v", step.path, err)
+		}
+		if requests := srv.Requests(); len(requests) != 1 || requests[0].Method != http.MethodPost || requests[0].Path != "/api/v1/alert/"+alert.Id+"/"+step.path {
+			t.Fatalf("%s sent %+v", step.path, requests)
+		}
+		got, err := hive.GetAlert(alert.Id)
+		if err != nil {
+			t.Fatalf("GetAlert: %v", err)
+		}
+		if got.Follow != step.follow || got.Read != step.read {
+			t.Fatalf("after %s: follow %v read %v", step.path, got.Follow, got.Read)
+		}
+	}
+
+	if err := hive.FollowAlert("~unknown"); !thehive5.IsNotFound(err) {
+		t.Fatalf("FollowAlert of an unknown alert = %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+/*
+thehive5 implements functionality to interact with the most recent version of thehive.
+https://www.strangebee.com/thehive/
+*/
+package thehive5
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// An Authenticator adds credentials to the requests sent to thehive5
+// Set it on Hivedata.Auth, if nil the Apikey is sent as Bearer token
+type Authenticator interface {
+	// Authenticate adds the credentials to an outgoing request
+	Authenticate(ctx context.Context, hive *Hivedata, req *http.Request) error
+	// Reauthenticate gets called after thehive5 rejected req with 401
+	// It returns true if the credentials were renewed and the request should be sent again
+	Reauthenticate(ctx context.Context, hive *Hivedata, req *http.Request) (bool, error)
+}
+
+// APIKeyAuth authenticates with an apikey sent as Bearer token
+type APIKeyAuth struct {
+	Key string
+}
+
+// Authenticate adds the Authorization header
+func (a *APIKeyAuth) Authenticate(ctx context.Context, hive *Hivedata, req *http.Request) error {
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", a.Key))
+	return nil
+}
+
+// Reauthenticate does nothing as an apikey can't be renewed
+func (a *APIKeyAuth) Reauthenticate(ctx context.Context, hive *Hivedata, req *http.Request) (bool, error) {
+	return false, nil
+}
+
+// BasicAuth authenticates with username and password on every request
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// Authenticate adds the basic auth header
+func (b *BasicAuth) Authenticate(ctx context.Context, hive *Hivedata, req *http.Request) error {
+	req.SetBasicAuth(b.Username, b.Password)
+	return nil
+}
+
+// Reauthenticate does nothing as the credentials are sent on every request
+func (b *BasicAuth) Reauthenticate(ctx context.Context, hive *Hivedata, req *http.Request) (bool, error) {
+	return false, nil
+}
+
+// SessionAuth logs in on /api/v1/login and authenticates with the returned session cookie
+// The session is renewed transparently once it expires
+type SessionAuth struct {
+	Username string
+	Password string
+
+	mu      sync.Mutex
+	cookies []*http.Cookie
+}
+
+// NewSessionAuth creates a new SessionAuth. The login happens on the first request
+func NewSessionAuth(username string, password string) *SessionAuth {
+	return &SessionAuth{Username: username, Password: password}
+}
+
+// Authenticate adds the session cookies and logs in if there is no session yet
+func (s *SessionAuth) Authenticate(ctx context.Context, hive *Hivedata, req *http.Request) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.cookies) == 0 {
+		if err := s.login(ctx, hive); err != nil {
+			return err
+		}
+	}
+
+	for _, cookie := range s.cookies {
+		req.AddCookie(cookie)
+		// thehive5 expects the xsrf token to be mirrored in a header when using cookies
+		if cookie.Name == "THEHIVE-XSRF-TOKEN" {
+			req.Header.Set("X-THEHIVE-XSRF-TOKEN", cookie.Value)
+		}
+	}
+	return nil
+}
+
+// Reauthenticate drops the expired session and logs in again
+// Requests failing concurrently with the same session cause a single login
+func (s *SessionAuth) Reauthenticate(ctx context.Context, hive *Hivedata, req *http.Request) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// another request already renewed the session after req was sent
+	if len(s.cookies) != 0 && !s.sentWith(req) {
+		return true, nil
+	}
+
+	s.cookies = nil
+	if err := s.login(ctx, hive); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Logout ends the session on thehive5
+func (s *SessionAuth) Logout(ctx context.Context, hive *Hivedata) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.cookies) == 0 {
+		return nil
+	}
+
+	loginUrl, err := url.JoinPath(hive.Url, "/api/v1/logout")
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, string(POST), loginUrl, nil)
+	if err != nil {
+		return err
+	}
+	for _, cookie := range s.cookies {
+		req.AddCookie(cookie)
+	}
+
+	_, err = s.send(hive, req)
+	s.cookies = nil
+	return err
+}
+
+// sentWith reports if req carries the current session cookies. The caller must hold the lock
+func (s *SessionAuth) sentWith(req *http.Request) bool {
+	for _, cookie := range s.cookies {
+		sent, err := req.Cookie(cookie.Name)
+		if err != nil || sent.Value != cookie.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// login requests a new session. The caller must hold the lock
+func (s *SessionAuth) login(ctx context.Context, hive *Hivedata) error {
+	loginUrl, err := url.JoinPath(hive.Url, "/api/v1/login")
+	if err != nil {
+		return err
+	}
+
+	jsondata, err := json.Marshal(map[string]string{"user": s.Username, "password": s.Password})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, string(POST), loginUrl, bytes.NewReader(jsondata))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.send(hive, req)
+	if err != nil {
+		return err
+	}
+
+	s.cookies = resp.Cookies()
+	if len(s.cookies) == 0 {
+		return fmt.Errorf("login succeeded but thehive5 returned no session cookie")
+	}
+	return nil
+}
+
+// send bypasses the retry and auth handling of the client to avoid recursion
+// The headers and the limiter of the client apply as for any other request
+func (s *SessionAuth) send(hive *Hivedata, req *http.Request) (*http.Response, error) {
+	req.Header.Set("Accept", "application/json")
+	hive.setHeaders(req)
+
+	release, err := hive.Limiter.acquire(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	resp, err := hive.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, newAPIError(req, resp, body)
+	}
+	return resp, nil
+}
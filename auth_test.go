@@ -0,0 +1,147 @@
+package thehive5_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	thehive5 "github.com/b401/goHive5"
+	"github.com/b401/goHive5/thehive5test"
+)
+
+// paths returns the method and path of the requests received by srv
+func paths(srv *thehive5test.Server) []string {
+	var paths []string
+	for _, req := range srv.Requests() {
+		paths = append(paths, req.Method+" "+req.Path)
+	}
+	return paths
+}
+
+func TestBasicAuth(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+
+	hive := srv.Hive(thehive5.WithBasicAuth(srv.User, srv.Password))
+	if _, err := hive.CreateCase(&thehive5.HiveCase{Title: "case", Description: "case"}); err != nil {
+		t.Fatalf("CreateCase: %v", err)
+	}
+	if got := srv.Requests()[0].Header.Get("Authorization"); !strings.HasPrefix(got, "Basic ") {
+		t.Fatalf("Authorization = %q", got)
+	}
+
+	// wrong credentials fail without a second attempt
+	srv.ClearRequests()
+	wrong := srv.Hive(thehive5.WithBasicAuth(srv.User, "wrong"))
+	if _, err := wrong.GetCase(1); !thehive5.IsAuthFailure(err) {
+		t.Fatalf("GetCase with a wrong password = %v", err)
+	}
+	if n := len(srv.Requests()); n != 1 {
+		t.Fatalf("sent %d requests, want 1", n)
+	}
+}
+
+func TestSessionAuth(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+
+	auth := thehive5.NewSessionAuth(srv.User, srv.Password)
+	hive := srv.Hive(thehive5.WithAuthenticator(auth), thehive5.WithUserAgent("soar/1.0"),
+		thehive5.WithHeader("X-Request-Source", "soar"), thehive5.WithDefaultOrganisation("tenant-a"))
+
+	created, err := hive.CreateCase(&thehive5.HiveCase{Title: "case", Description: "case"})
+	if err != nil {
+		t.Fatalf("CreateCase: %v", err)
+	}
+	if _, err := hive.GetCase(created.Number); err != nil {
+		t.Fatalf("GetCase: %v", err)
+	}
+	want := []string{"POST /api/v1/login", "POST /api/v1/case", "GET /api/v1/case/1"}
+	if got := paths(srv); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("requests = %v, want %v", got, want)
+	}
+
+	// the login is sent like any other request of the client
+	login := srv.Requests()[0]
+	if login.Body["user"] != srv.User || login.Header.Get("User-Agent") != "soar/1.0" ||
+		login.Header.Get("X-Request-Source") != "soar" || login.Header.Get("X-Organisation") != "tenant-a" {
+		t.Fatalf("login request %+v", login)
+	}
+	if got := srv.Requests()[1].Header.Get("X-THEHIVE-XSRF-TOKEN"); got == "" {
+		t.Fatal("the xsrf token isn't mirrored in a header")
+	}
+
+	// an expired session is renewed once and the request is sent again
+	srv.ExpireSessions()
+	srv.ClearRequests()
+	if _, err := hive.GetCase(created.Number); err != nil {
+		t.Fatalf("GetCase with an expired session: %v", err)
+	}
+	want = []string{"GET /api/v1/case/1", "POST /api/v1/login", "GET /api/v1/case/1"}
+	if got := paths(srv); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("requests = %v, want %v", got, want)
+	}
+
+	// after the logout the next request logs in again
+	srv.ClearRequests()
+	if err := auth.Logout(context.Background(), hive); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if _, err := hive.GetCase(created.Number); err != nil {
+		t.Fatalf("GetCase after the logout: %v", err)
+	}
+	want = []string{"POST /api/v1/logout", "POST /api/v1/login", "GET /api/v1/case/1"}
+	if got := paths(srv); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("requests = %v, want %v", got, want)
+	}
+}
+
+func TestSessionAuthWrongPassword(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+
+	hive := srv.Hive(thehive5.WithSessionAuth(srv.User, "wrong"))
+	if _, err := hive.GetCase(1); !thehive5.IsAuthFailure(err) {
+		t.Fatalf("GetCase with a wrong password = %v", err)
+	}
+	if got := paths(srv); len(got) != 1 || got[0] != "POST /api/v1/login" {
+		t.Fatalf("requests = %v", got)
+	}
+}
+
+func TestSessionAuthRenewsOnce(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+
+	// a single slot makes sure the login doesn't wait for the request which triggered it
+	hive := srv.Hive(thehive5.WithSessionAuth(srv.User, srv.Password), thehive5.WithLimiter(thehive5.NewLimiter(0, 0, 1)))
+	created, err := hive.CreateCase(&thehive5.HiveCase{Title: "case", Description: "case"})
+	if err != nil {
+		t.Fatalf("CreateCase: %v", err)
+	}
+
+	srv.ExpireSessions()
+	srv.ClearRequests()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := hive.GetCase(created.Number); err != nil {
+				t.Errorf("GetCase: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	logins := 0
+	for _, path := range paths(srv) {
+		if path == "POST /api/v1/login" {
+			logins++
+		}
+	}
+	if logins != 1 {
+		t.Fatalf("%d logins after the session expired, want 1", logins)
+	}
+}
@@ -0,0 +1,264 @@
+/*
+thehive5 implements functionality to interact with the most recent version of thehive.
+https://www.strangebee.com/thehive/
+*/
+package thehive5
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// An Expr is a node of a filter expression for the query endpoint
+// Create it with Eq, Gte, In, And, Or, Not... and pass it to QueryBuilder.Where
+// time.Time values are converted into millisecond timestamps automatically
+type Expr struct {
+	operator string
+	field    string
+	value    interface{}
+	values   []interface{}
+	children []Expr
+}
+
+// Eq matches if field equals value
+func Eq(field string, value interface{}) Expr {
+	return Expr{operator: "_eq", field: field, value: value}
+}
+
+// Ne matches if field doesn't equal value
+func Ne(field string, value interface{}) Expr {
+	return Expr{operator: "_ne", field: field, value: value}
+}
+
+// Lt matches if field is lower than value
+func Lt(field string, value interface{}) Expr {
+	return Expr{operator: "_lt", field: field, value: value}
+}
+
+// Lte matches if field is lower or equal than value
+func Lte(field string, value interface{}) Expr {
+	return Expr{operator: "_lte", field: field, value: value}
+}
+
+// Gt matches if field is greater than value
+func Gt(field string, value interface{}) Expr {
+	return Expr{operator: "_gt", field: field, value: value}
+}
+
+// Gte matches if field is greater or equal than value
+func Gte(field string, value interface{}) Expr {
+	return Expr{operator: "_gte", field: field, value: value}
+}
+
+// Like matches if field matches the wildcard pattern value
+func Like(field string, value string) Expr {
+	return Expr{operator: "_like", field: field, value: value}
+}
+
+// StartsWith matches if field starts with value
+func StartsWith(field string, value string) Expr {
+	return Expr{operator: "_startsWith", field: field, value: value}
+}
+
+// EndsWith matches if field ends with value
+func EndsWith(field string, value string) Expr {
+	return Expr{operator: "_endsWith", field: field, value: value}
+}
+
+// Match does a full text search of value on field
+func Match(field string, value string) Expr {
+	return Expr{operator: "_match", field: field, value: value}
+}
+
+// In matches if field equals one of values
+func In(field string, values ...interface{}) Expr {
+	return Expr{operator: "_in", field: field, values: values}
+}
+
+// Between matches if field is between from (inclusive) and to (exclusive)
+func Between(field string, from interface{}, to interface{}) Expr {
+	return Expr{operator: "_between", field: field, values: []interface{}{from, to}}
+}
+
+// Contains matches if field is set
+func Contains(field string) Expr {
+	return Expr{operator: "_contains", field: field}
+}
+
+// And matches if all exprs match
+func And(exprs ...Expr) Expr {
+	return Expr{operator: "_and", children: exprs}
+}
+
+// Or matches if any of the exprs matches
+func Or(exprs ...Expr) Expr {
+	return Expr{operator: "_or", children: exprs}
+}
+
+// Not negates expr
+func Not(expr Expr) Expr {
+	return Expr{operator: "_not", children: []Expr{expr}}
+}
+
+// IsZero reports if the expression is empty
+func (e Expr) IsZero() bool {
+	return len(e.operator) == 0
+}
+
+// MarshalJSON converts the expression tree into thehive5 filter syntax
+func (e Expr) MarshalJSON() ([]byte, error) {
+	var operand interface{}
+
+	switch e.operator {
+	case "":
+		return []byte("{}"), nil
+	case "_and", "_or":
+		operand = e.children
+	case "_not":
+		operand = e.children[0]
+	case "_contains":
+		operand = e.field
+	case "_in":
+		values := make([]interface{}, len(e.values))
+		for i, v := range e.values {
+			values[i] = convertQueryValue(v)
+		}
+		operand = map[string]interface{}{"_field": e.field, "_values": values}
+	case "_between":
+		operand = map[string]interface{}{"_field": e.field, "_from": convertQueryValue(e.values[0]), "_to": convertQueryValue(e.values[1])}
+	default:
+		operand = map[string]interface{}{"_field": e.field, "_value": convertQueryValue(e.value)}
+	}
+
+	return json.Marshal(map[string]interface{}{e.operator: operand})
+}
+
+// convertQueryValue converts values thehive5 can't handle natively
+func convertQueryValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case time.Time:
+		return value.UTC().UnixMilli()
+	case *time.Time:
+		if value == nil {
+			return nil
+		}
+		return value.UTC().UnixMilli()
+	}
+	return v
+}
+
+// A SortOrder defines the direction of a sort stage
+type SortOrder string
+
+// Constants to handle the sort direction
+const (
+	Asc  SortOrder = "asc"
+	Desc SortOrder = "desc"
+)
+
+// A QueryBuilder assembles the stages of a query for the /api/v1/query endpoint
+//
+//	query := thehive5.Cases().
+//		Where(thehive5.Eq("status", "New")).
+//		And(thehive5.Gte("_createdAt", time.Now().Add(-24*time.Hour))).
+//		SortBy("_updatedAt", thehive5.Desc).
+//		Page(0, 100).
+//		Build()
+//
+//	cases, err := hive.FindCase(query)
+type QueryBuilder struct {
+	stages   []SearchQuery
+	filter   Expr
+	sorts    []map[string]string
+	from, to int
+	paged    bool
+}
+
+// NewQueryBuilder starts a query with the given stages, e.g. getCase followed by observables
+func NewQueryBuilder(stages ...SearchQuery) *QueryBuilder {
+	return &QueryBuilder{stages: stages}
+}
+
+// Cases starts a query on all cases
+func Cases() *QueryBuilder {
+	return NewQueryBuilder(SearchQuery{Name: "listCase"})
+}
+
+// Alerts starts a query on all alerts
+func Alerts() *QueryBuilder {
+	return NewQueryBuilder(SearchQuery{Name: "listAlert"})
+}
+
+// Observables starts a query on all observables
+func Observables() *QueryBuilder {
+	return NewQueryBuilder(SearchQuery{Name: "listObservable"})
+}
+
+// Tasks starts a query on all tasks
+func Tasks() *QueryBuilder {
+	return NewQueryBuilder(SearchQuery{Name: "listTask"})
+}
+
+// Where sets the filter of the query. If a filter already exists both are combined with And
+func (b *QueryBuilder) Where(expr Expr) *QueryBuilder {
+	return b.And(expr)
+}
+
+// And combines the current filter and expr so both have to match
+func (b *QueryBuilder) And(expr Expr) *QueryBuilder {
+	b.filter = combine("_and", b.filter, expr)
+	return b
+}
+
+// Or combines the current filter and expr so either of them has to match
+func (b *QueryBuilder) Or(expr Expr) *QueryBuilder {
+	b.filter = combine("_or", b.filter, expr)
+	return b
+}
+
+// combine joins two expressions with operator, chains of the same operator are kept flat
+func combine(operator string, current Expr, expr Expr) Expr {
+	if current.IsZero() {
+		return expr
+	}
+	if current.operator == operator {
+		children := append(append([]Expr{}, current.children...), expr)
+		return Expr{operator: operator, children: children}
+	}
+	return Expr{operator: operator, children: []Expr{current, expr}}
+}
+
+// SortBy adds a sort field. Multiple calls sort by multiple fields in the given order
+func (b *QueryBuilder) SortBy(field string, order SortOrder) *QueryBuilder {
+	b.sorts = append(b.sorts, map[string]string{field: string(order)})
+	return b
+}
+
+// Page limits the results to the range from (inclusive) to (exclusive)
+// Don't set a page if the query is used with NewQuery as it does the paging itself
+func (b *QueryBuilder) Page(from int, to int) *QueryBuilder {
+	b.from = from
+	b.to = to
+	b.paged = true
+	return b
+}
+
+// Build returns the stages which can be passed to FindCase, NewQuery etc.
+func (b *QueryBuilder) Build() []SearchQuery {
+	stages := append([]SearchQuery{}, b.stages...)
+
+	if !b.filter.IsZero() {
+		filter := b.filter
+		stages = append(stages, SearchQuery{Name: "filter", Filter: &filter})
+	}
+
+	if len(b.sorts) != 0 {
+		stages = append(stages, SearchQuery{Name: "sort", SortFields: append([]map[string]string{}, b.sorts...)})
+	}
+
+	if b.paged {
+		stages = append(stages, SearchQuery{Name: "page", ScopeFrom: b.from, ScopeTo: b.to})
+	}
+
+	return stages
+}
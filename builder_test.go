@@ -0,0 +1,112 @@
+package thehive5_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	thehive5 "github.com/b401/goHive5"
+)
+
+func TestExprJSON(t *testing.T) {
+	// 1700000000000 in milliseconds, the zone must not change the timestamp
+	detected := time.Date(2023, 11, 14, 23, 13, 20, 0, time.FixedZone("CET", 3600))
+	var unset *time.Time
+
+	tests := []struct {
+		name string
+		expr thehive5.Expr
+		want string
+	}{
+		{"empty", thehive5.Expr{}, `{}`},
+		{"eq", thehive5.Eq("status", "New"), `{"_eq":{"_field":"status","_value":"New"}}`},
+		{"ne", thehive5.Ne("severity", 1), `{"_ne":{"_field":"severity","_value":1}}`},
+		{"lt", thehive5.Lt("tlp", 3), `{"_lt":{"_field":"tlp","_value":3}}`},
+		{"lte", thehive5.Lte("tlp", 3), `{"_lte":{"_field":"tlp","_value":3}}`},
+		{"gt", thehive5.Gt("pap", 0), `{"_gt":{"_field":"pap","_value":0}}`},
+		{"like", thehive5.Like("title", "phish*"), `{"_like":{"_field":"title","_value":"phish*"}}`},
+		{"startsWith", thehive5.StartsWith("title", "[SIEM]"), `{"_startsWith":{"_field":"title","_value":"[SIEM]"}}`},
+		{"endsWith", thehive5.EndsWith("data", ".example"), `{"_endsWith":{"_field":"data","_value":".example"}}`},
+		{"match", thehive5.Match("description", "invoice"), `{"_match":{"_field":"description","_value":"invoice"}}`},
+		{"contains", thehive5.Contains("assignee"), `{"_contains":"assignee"}`},
+		{"in", thehive5.In("status", "New", "InProgress"), `{"_in":{"_field":"status","_values":["New","InProgress"]}}`},
+		{"between", thehive5.Between("severity", 2, 4), `{"_between":{"_field":"severity","_from":2,"_to":4}}`},
+		{"and", thehive5.And(thehive5.Eq("status", "New"), thehive5.Gte("severity", 3)),
+			`{"_and":[{"_eq":{"_field":"status","_value":"New"}},{"_gte":{"_field":"severity","_value":3}}]}`},
+		{"or", thehive5.Or(thehive5.Eq("tags", "phishing"), thehive5.Eq("tags", "spam")),
+			`{"_or":[{"_eq":{"_field":"tags","_value":"phishing"}},{"_eq":{"_field":"tags","_value":"spam"}}]}`},
+		{"not", thehive5.Not(thehive5.In("status", "Duplicated")), `{"_not":{"_in":{"_field":"status","_values":["Duplicated"]}}}`},
+		{"nested", thehive5.And(thehive5.Not(thehive5.Eq("flag", true)), thehive5.Or(thehive5.Lt("tlp", 2), thehive5.Contains("pap"))),
+			`{"_and":[{"_not":{"_eq":{"_field":"flag","_value":true}}},{"_or":[{"_lt":{"_field":"tlp","_value":2}},{"_contains":"pap"}]}]}`},
+		{"time", thehive5.Gte("_createdAt", detected), `{"_gte":{"_field":"_createdAt","_value":1700000000000}}`},
+		{"time pointer", thehive5.Lt("_createdAt", &detected), `{"_lt":{"_field":"_createdAt","_value":1700000000000}}`},
+		{"nil time pointer", thehive5.Eq("endDate", unset), `{"_eq":{"_field":"endDate","_value":null}}`},
+		{"time in", thehive5.In("_createdAt", detected, detected.Add(time.Second)),
+			`{"_in":{"_field":"_createdAt","_values":[1700000000000,1700000001000]}}`},
+		{"time between", thehive5.Between("_createdAt", detected, detected.Add(time.Hour)),
+			`{"_between":{"_field":"_createdAt","_from":1700000000000,"_to":1700003600000}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryBuilderStages(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *thehive5.QueryBuilder
+		want    string
+	}{
+		{"list only", thehive5.Cases(), `[{"_name":"listCase"}]`},
+		{"single filter", thehive5.Alerts().Where(thehive5.Eq("status", "New")),
+			`[{"_name":"listAlert"},{"_eq":{"_field":"status","_value":"New"},"_name":"filter"}]`},
+		{"and chain stays flat", thehive5.Observables().Where(thehive5.Eq("ioc", true)).And(thehive5.Eq("sighted", true)).And(thehive5.Gt("tlp", 1)),
+			`[{"_name":"listObservable"},{"_and":[{"_eq":{"_field":"ioc","_value":true}},{"_eq":{"_field":"sighted","_value":true}},{"_gt":{"_field":"tlp","_value":1}}],"_name":"filter"}]`},
+		{"or after and nests", thehive5.Tasks().Where(thehive5.Eq("status", "Waiting")).And(thehive5.Eq("flag", true)).Or(thehive5.Eq("mandatory", true)),
+			`[{"_name":"listTask"},{"_name":"filter","_or":[{"_and":[{"_eq":{"_field":"status","_value":"Waiting"}},{"_eq":{"_field":"flag","_value":true}}]},{"_eq":{"_field":"mandatory","_value":true}}]}]`},
+		{"multi field sort", thehive5.Cases().SortBy("_updatedAt", thehive5.Desc).SortBy("number", thehive5.Asc),
+			`[{"_name":"listCase"},{"_fields":[{"_updatedAt":"desc"},{"number":"asc"}],"_name":"sort"}]`},
+		{"page", thehive5.Cases().Page(10, 20), `[{"_name":"listCase"},{"from":10,"to":20,"_name":"page"}]`},
+		{"first page", thehive5.Cases().Page(0, 15), `[{"_name":"listCase"},{"from":0,"to":15,"_name":"page"}]`},
+		{"all stages", thehive5.Cases().Where(thehive5.Eq("status", "New")).And(thehive5.Gte("_createdAt", time.UnixMilli(1700000000000))).SortBy("_updatedAt", thehive5.Desc).Page(0, 100),
+			`[{"_name":"listCase"},{"_and":[{"_eq":{"_field":"status","_value":"New"}},{"_gte":{"_field":"_createdAt","_value":1700000000000}}],"_name":"filter"},{"_fields":[{"_updatedAt":"desc"}],"_name":"sort"},{"from":0,"to":100,"_name":"page"}]`},
+		{"custom start", thehive5.NewQueryBuilder(thehive5.SearchQuery{Name: "getCase", IdOrName: "~123"}, thehive5.SearchQuery{Name: "observables"}).Where(thehive5.Eq("dataType", "ip")),
+			`[{"_name":"getCase","idOrName":"~123"},{"_name":"observables"},{"_eq":{"_field":"dataType","_value":"ip"},"_name":"filter"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.builder.Build())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryBuilderBuildCopies(t *testing.T) {
+	builder := thehive5.Cases().Where(thehive5.Eq("status", "New")).SortBy("number", thehive5.Asc)
+	first := builder.Build()
+	builder.And(thehive5.Eq("severity", 3)).SortBy("_createdAt", thehive5.Desc)
+
+	got, err := json.Marshal(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"_name":"listCase"},{"_eq":{"_field":"status","_value":"New"},"_name":"filter"},{"_fields":[{"number":"asc"}],"_name":"sort"}]`
+	if string(got) != want {
+		t.Fatalf("stages changed after Build:\ngot  %s\nwant %s", got, want)
+	}
+}
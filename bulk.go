@@ -0,0 +1,301 @@
+/*
+thehive5 implements functionality to interact with the most recent version of thehive.
+https://www.strangebee.com/thehive/
+*/
+package thehive5
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// A BulkItemResult contains the outcome of a bulk operation for a single object
+type BulkItemResult struct {
+	Id  string
+	Err error
+}
+
+// A BulkResult contains the outcome of a bulk operation for every object in the requested order
+type BulkResult []BulkItemResult
+
+// Failed returns the items which couldn't be processed
+func (b BulkResult) Failed() BulkResult {
+	var failed BulkResult
+	for _, item := range b {
+		if item.Err != nil {
+			failed = append(failed, item)
+		}
+	}
+	return failed
+}
+
+// Err joins the errors of all failed items, nil if all succeeded
+func (b BulkResult) Err() error {
+	var errs []error
+	for _, item := range b.Failed() {
+		errs = append(errs, item.Err)
+	}
+	return errors.Join(errs...)
+}
+
+// runBulk sends a bulk request. thehive5 processes bulk requests all or nothing,
+// so if it rejects an item (400/404) every item is sent on its own to find the failing ones
+// Other errors (e.g. 401, 429, 5xx or a canceled context) are returned as they are,
+// sending more requests wouldn't succeed either
+func runBulk(ids []string, bulk func() error, single func(id string) error) (BulkResult, error) {
+	result := make(BulkResult, len(ids))
+	for i, id := range ids {
+		result[i].Id = id
+	}
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	err := bulk()
+	if err == nil {
+		return result, nil
+	}
+	if !isItemError(err) {
+		return nil, err
+	}
+
+	for i, id := range ids {
+		err := single(id)
+		if err != nil && !isItemError(err) {
+			return nil, err
+		}
+		result[i].Err = err
+	}
+	return result, nil
+}
+
+// isItemError reports if thehive5 rejected the request because of the object itself
+func isItemError(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusBadRequest || apiErr.StatusCode == http.StatusNotFound
+}
+
+// alertUpdateBody converts an update into a generic json object
+// The type set by HiveUpdateAlert.MarshalJSON is dropped unless the caller set it,
+// otherwise all alerts would be changed to the type "alert"
+func alertUpdateBody(update *HiveUpdateAlert) (map[string]interface{}, error) {
+	updateCopy := *update
+	jsondata, err := json.Marshal(&updateCopy)
+	if err != nil {
+		return nil, err
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(jsondata, &body); err != nil {
+		return nil, err
+	}
+	if len(update.Type) == 0 {
+		delete(body, "type")
+	}
+	return body, nil
+}
+
+// BulkUpdateAlerts applies the same update to all alerts, e.g. to close or assign them
+func (hive *Hivedata) BulkUpdateAlerts(alertIds []string, update *HiveUpdateAlert) (BulkResult, error) {
+	return hive.BulkUpdateAlertsContext(context.Background(), alertIds, update)
+}
+
+// BulkUpdateAlertsContext is like BulkUpdateAlerts but uses ctx for the requests
+func (hive *Hivedata) BulkUpdateAlertsContext(ctx context.Context, alertIds []string, update *HiveUpdateAlert) (BulkResult, error) {
+	body, err := alertUpdateBody(update)
+	if err != nil {
+		return nil, err
+	}
+
+	return runBulk(alertIds, func() error {
+		url, err := url.JoinPath(hive.Url, "/api/v1/alert/_bulk")
+		if err != nil {
+			return err
+		}
+
+		body["ids"] = alertIds
+		jsondata, err := json.Marshal(body)
+		delete(body, "ids")
+		if err != nil {
+			return err
+		}
+
+		_, err = hive.webRequest(ctx, url, PATCH, jsondata)
+		return err
+	}, func(id string) error {
+		return hive.patchAlert(ctx, id, body)
+	})
+}
+
+// BulkDeleteAlerts deletes all alerts
+func (hive *Hivedata) BulkDeleteAlerts(alertIds []string) (BulkResult, error) {
+	return hive.BulkDeleteAlertsContext(context.Background(), alertIds)
+}
+
+// BulkDeleteAlertsContext is like BulkDeleteAlerts but uses ctx for the requests
+func (hive *Hivedata) BulkDeleteAlertsContext(ctx context.Context, alertIds []string) (BulkResult, error) {
+	return runBulk(alertIds, func() error {
+		url, err := url.JoinPath(hive.Url, "/api/v1/alert/delete/_bulk")
+		if err != nil {
+			return err
+		}
+
+		jsondata, err := json.Marshal(map[string][]string{"ids": alertIds})
+		if err != nil {
+			return err
+		}
+
+		_, err = hive.webRequest(ctx, url, POST, jsondata)
+		return err
+	}, func(id string) error {
+		return hive.DeleteAlertContext(ctx, id)
+	})
+}
+
+// MergeAlertsIntoCase merges all alerts into an existing case
+func (hive *Hivedata) MergeAlertsIntoCase(caseNumber int, alertIds []string) (BulkResult, error) {
+	return hive.MergeAlertsIntoCaseContext(context.Background(), caseNumber, alertIds)
+}
+
+// MergeAlertsIntoCaseContext is like MergeAlertsIntoCase but uses ctx for the requests
+func (hive *Hivedata) MergeAlertsIntoCaseContext(ctx context.Context, caseNumber int, alertIds []string) (BulkResult, error) {
+	return runBulk(alertIds, func() error {
+		url, err := url.JoinPath(hive.Url, "/api/v1/alert/merge/_bulk")
+		if err != nil {
+			return err
+		}
+
+		jsondata, err := json.Marshal(map[string]interface{}{"caseId": strconv.Itoa(caseNumber), "alertIds": alertIds})
+		if err != nil {
+			return err
+		}
+
+		_, err = hive.webRequest(ctx, url, POST, jsondata)
+		return err
+	}, func(id string) error {
+		return hive.MergeAlertContext(ctx, id, caseNumber)
+	})
+}
+
+// caseIds converts case numbers into the ids of a BulkResult
+func caseIds(caseNumbers []int) []string {
+	ids := make([]string, len(caseNumbers))
+	for i, number := range caseNumbers {
+		ids[i] = strconv.Itoa(number)
+	}
+	return ids
+}
+
+// BulkUpdateCases applies the same update to all cases, e.g. to reassign them
+func (hive *Hivedata) BulkUpdateCases(caseNumbers []int, update *HiveUpdateCase) (BulkResult, error) {
+	return hive.BulkUpdateCasesContext(context.Background(), caseNumbers, update)
+}
+
+// BulkUpdateCasesContext is like BulkUpdateCases but uses ctx for the requests
+func (hive *Hivedata) BulkUpdateCasesContext(ctx context.Context, caseNumbers []int, update *HiveUpdateCase) (BulkResult, error) {
+	jsondata, err := json.Marshal(update)
+	if err != nil {
+		return nil, err
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(jsondata, &body); err != nil {
+		return nil, err
+	}
+
+	ids := caseIds(caseNumbers)
+	return runBulk(ids, func() error {
+		url, err := url.JoinPath(hive.Url, "/api/v1/case/_bulk")
+		if err != nil {
+			return err
+		}
+
+		body["ids"] = ids
+		jsondata, err := json.Marshal(body)
+		delete(body, "ids")
+		if err != nil {
+			return err
+		}
+
+		_, err = hive.webRequest(ctx, url, PATCH, jsondata)
+		return err
+	}, func(id string) error {
+		number, _ := strconv.Atoi(id)
+		return hive.UpdateCaseContext(ctx, number, update)
+	})
+}
+
+// BulkDeleteCases deletes all cases
+// thehive5 has no bulk endpoint to delete cases, so every case is deleted on its own
+func (hive *Hivedata) BulkDeleteCases(caseNumbers []int) (BulkResult, error) {
+	return hive.BulkDeleteCasesContext(context.Background(), caseNumbers)
+}
+
+// BulkDeleteCasesContext is like BulkDeleteCases but uses ctx for the requests
+func (hive *Hivedata) BulkDeleteCasesContext(ctx context.Context, caseNumbers []int) (BulkResult, error) {
+	result := make(BulkResult, len(caseNumbers))
+	for i, number := range caseNumbers {
+		err := hive.DeleteCaseContext(ctx, number)
+		if err != nil && !isItemError(err) {
+			return nil, err
+		}
+		result[i] = BulkItemResult{Id: strconv.Itoa(number), Err: err}
+	}
+	return result, nil
+}
+
+// BulkUpdateCasesByQuery applies the update to all cases returned by a case query, see FindCase
+// With dryRun nothing is changed, the returned cases are the ones which would be updated
+func (hive *Hivedata) BulkUpdateCasesByQuery(searchQuery []SearchQuery, update *HiveUpdateCase, dryRun bool) ([]HiveCaseResponse, BulkResult, error) {
+	return hive.BulkUpdateCasesByQueryContext(context.Background(), searchQuery, update, dryRun)
+}
+
+// BulkUpdateCasesByQueryContext is like BulkUpdateCasesByQuery but uses ctx for the requests
+func (hive *Hivedata) BulkUpdateCasesByQueryContext(ctx context.Context, searchQuery []SearchQuery, update *HiveUpdateCase, dryRun bool) ([]HiveCaseResponse, BulkResult, error) {
+	cases, numbers, err := hive.casesByQuery(ctx, searchQuery)
+	if err != nil || dryRun {
+		return cases, nil, err
+	}
+
+	result, err := hive.BulkUpdateCasesContext(ctx, numbers, update)
+	return cases, result, err
+}
+
+// BulkDeleteCasesByQuery deletes all cases returned by a case query, see FindCase
+// With dryRun nothing is deleted, the returned cases are the ones which would be deleted
+func (hive *Hivedata) BulkDeleteCasesByQuery(searchQuery []SearchQuery, dryRun bool) ([]HiveCaseResponse, BulkResult, error) {
+	return hive.BulkDeleteCasesByQueryContext(context.Background(), searchQuery, dryRun)
+}
+
+// BulkDeleteCasesByQueryContext is like BulkDeleteCasesByQuery but uses ctx for the requests
+func (hive *Hivedata) BulkDeleteCasesByQueryContext(ctx context.Context, searchQuery []SearchQuery, dryRun bool) ([]HiveCaseResponse, BulkResult, error) {
+	cases, numbers, err := hive.casesByQuery(ctx, searchQuery)
+	if err != nil || dryRun {
+		return cases, nil, err
+	}
+
+	result, err := hive.BulkDeleteCasesContext(ctx, numbers)
+	return cases, result, err
+}
+
+// casesByQuery returns all cases of a query and their numbers
+// The query must not be paged, all pages are fetched
+func (hive *Hivedata) casesByQuery(ctx context.Context, searchQuery []SearchQuery) ([]HiveCaseResponse, []int, error) {
+	cases, err := NewQueryContext[HiveCaseResponse](ctx, hive, searchQuery...).All()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	numbers := make([]int, len(cases))
+	for i, c := range cases {
+		numbers[i] = c.Number
+	}
+	return cases, numbers, nil
+}
@@ -0,0 +1,185 @@
+package thehive5_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	thehive5 "github.com/b401/goHive5"
+	"github.com/b401/goHive5/thehive5test"
+)
+
+// newBulkServer creates three alerts on a fake server which answers bulk requests with status
+// Status 0 passes the bulk requests on to the fake server
+func newBulkServer(t *testing.T, status int) (*thehive5test.Server, *thehive5.Hivedata, []string) {
+	t.Helper()
+	srv := thehive5test.NewServer()
+	t.Cleanup(srv.Close)
+	hive := srv.Hive()
+
+	var ids []string
+	for _, ref := range []string{"1", "2", "3"} {
+		alert, err := hive.CreateAlert(&thehive5.HiveAlert{Type: "external", Source: "test", SourceRef: ref, Title: "alert", Description: "alert"})
+		if err != nil {
+			t.Fatalf("CreateAlert: %v", err)
+		}
+		ids = append(ids, alert.Id)
+	}
+
+	if status != 0 {
+		srv.Intercept = func(req thehive5test.Request) error {
+			if strings.HasSuffix(req.Path, "/_bulk") {
+				return thehive5test.Error(status, "Failure", "bulk failed")
+			}
+			return nil
+		}
+	}
+	srv.ClearRequests()
+	return srv, hive, ids
+}
+
+func TestBulkFallsBackOnUnknownId(t *testing.T) {
+	srv, hive, ids := newBulkServer(t, 0)
+
+	result, err := hive.BulkDeleteAlerts(append(ids, "~404"))
+	if err != nil {
+		t.Fatalf("BulkDeleteAlerts: %v", err)
+	}
+	// the rejected bulk request and one request per alert
+	if n := len(srv.Requests()); n != 5 {
+		t.Fatalf("sent %d requests, want 5", n)
+	}
+
+	failed := result.Failed()
+	if len(failed) != 1 || failed[0].Id != "~404" || !errors.Is(failed[0].Err, thehive5.ErrNotFound) {
+		t.Fatalf("failed items = %v", failed)
+	}
+	if !errors.Is(result.Err(), thehive5.ErrNotFound) {
+		t.Fatalf("result.Err() = %v", result.Err())
+	}
+	if alerts := srv.Objects(thehive5test.KindAlert); len(alerts) != 0 {
+		t.Fatalf("%d alerts left", len(alerts))
+	}
+}
+
+func TestBulkSucceeds(t *testing.T) {
+	srv, hive, ids := newBulkServer(t, 0)
+
+	result, err := hive.BulkUpdateAlerts(ids, &thehive5.HiveUpdateAlert{Assignee: "analyst"})
+	if err != nil || result.Err() != nil || len(result) != len(ids) {
+		t.Fatalf("BulkUpdateAlerts = %v, %v", result, err)
+	}
+	if n := len(srv.Requests()); n != 1 {
+		t.Fatalf("sent %d requests, want 1", n)
+	}
+	for _, alert := range srv.Objects(thehive5test.KindAlert) {
+		if alert["assignee"] != "analyst" {
+			t.Fatalf("alert %v wasn't updated", alert["_id"])
+		}
+	}
+}
+
+func TestBulkDoesNotFallBackOnServerErrors(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			srv, hive, ids := newBulkServer(t, status)
+			// expired sessions are renewed by the Authenticator, here every 401 is final
+			hive.Auth = nil
+
+			result, err := hive.BulkDeleteAlerts(ids)
+			var apiErr *thehive5.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != status {
+				t.Fatalf("BulkDeleteAlerts error = %v, want APIError %d", err, status)
+			}
+			if result != nil {
+				t.Fatalf("result = %v, want nil", result)
+			}
+			if n := len(srv.Requests()); n != 1 {
+				t.Fatalf("sent %d requests, want only the bulk request", n)
+			}
+			if alerts := srv.Objects(thehive5test.KindAlert); len(alerts) != len(ids) {
+				t.Fatalf("%d alerts left, want %d", len(alerts), len(ids))
+			}
+		})
+	}
+}
+
+func TestBulkAlerts(t *testing.T) {
+	srv, hive, ids := newBulkServer(t, 0)
+
+	result, err := hive.BulkUpdateAlerts(ids[:2], &thehive5.HiveUpdateAlert{Status: "Ignored"})
+	if err != nil || result.Err() != nil {
+		t.Fatalf("BulkUpdateAlerts: %v %v", err, result.Err())
+	}
+	for _, alert := range srv.Objects(thehive5test.KindAlert) {
+		want := "Ignored"
+		if alert["_id"] == ids[2] {
+			want = "New"
+		}
+		if alert["status"] != want || alert["type"] != "external" {
+			t.Fatalf("alert %v not updated correctly: %v %v", alert["_id"], alert["status"], alert["type"])
+		}
+	}
+
+	// the unknown id rejects the bulk request, every alert is retried on its own
+	result, err = hive.BulkDeleteAlerts([]string{ids[0], "~unknown"})
+	if err != nil {
+		t.Fatalf("BulkDeleteAlerts: %v", err)
+	}
+	failed := result.Failed()
+	if len(failed) != 1 || failed[0].Id != "~unknown" || !errors.Is(failed[0].Err, thehive5.ErrNotFound) {
+		t.Fatalf("BulkDeleteAlerts failed items = %v", failed)
+	}
+	if alerts := srv.Objects(thehive5test.KindAlert); len(alerts) != 2 || alerts[0]["_id"] != ids[1] {
+		t.Fatalf("remaining alerts = %v", alerts)
+	}
+
+	target, err := hive.CreateCase(&thehive5.HiveCase{Title: "case", Description: "case"})
+	if err != nil {
+		t.Fatalf("CreateCase: %v", err)
+	}
+	result, err = hive.MergeAlertsIntoCase(target.Number, ids[1:])
+	if err != nil || result.Err() != nil {
+		t.Fatalf("MergeAlertsIntoCase: %v %v", err, result.Err())
+	}
+	alerts, err := hive.GetCaseAlerts(target.Number)
+	if err != nil || len(alerts) != 2 {
+		t.Fatalf("GetCaseAlerts = %v, %v", alerts, err)
+	}
+}
+
+func TestBulkCases(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	hive := srv.Hive()
+	createCases(t, hive, "low", "low", "high")
+
+	low := thehive5.Cases().Where(thehive5.Eq("severity", int(thehive5.SeverityLow))).Build()
+	matched, result, err := hive.BulkUpdateCasesByQuery(low, &thehive5.HiveUpdateCase{Assignee: "analyst"}, true)
+	if err != nil || len(matched) != 2 || result != nil {
+		t.Fatalf("dry run = %d cases, %v, %v", len(matched), result, err)
+	}
+	for _, c := range srv.Objects(thehive5test.KindCase) {
+		if _, ok := c["assignee"]; ok {
+			t.Fatalf("dry run changed case %v", c["number"])
+		}
+	}
+
+	_, result, err = hive.BulkUpdateCasesByQuery(low, &thehive5.HiveUpdateCase{Assignee: "analyst"}, false)
+	if err != nil || result.Err() != nil || len(result) != 2 {
+		t.Fatalf("BulkUpdateCasesByQuery = %v, %v", result, err)
+	}
+	assigned, err := hive.CountCases(filter(thehive5.Eq("assignee", "analyst")))
+	if err != nil || assigned != 2 {
+		t.Fatalf("assigned cases = %d, %v", assigned, err)
+	}
+
+	result, err = hive.BulkDeleteCases([]int{1, 99})
+	if err != nil {
+		t.Fatalf("BulkDeleteCases: %v", err)
+	}
+	if failed := result.Failed(); len(failed) != 1 || failed[0].Id != "99" {
+		t.Fatalf("BulkDeleteCases failed items = %v", failed)
+	}
+}
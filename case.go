@@ -5,7 +5,9 @@ https://www.strangebee.com/thehive/
 package thehive5
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"strconv"
@@ -185,15 +187,14 @@ func (hu *HiveUpdateCase) MarshalJSON() ([]byte, error) {
 	}
 
 	if hu.ImpactStatus != nil {
-		switch strings.ToLower(*hu.ImpactStatus) {
-		case "withimpact":
-			*hu.ImpactStatus = "WithImpact"
-		case "noimpact":
-			*hu.ImpactStatus = "NoImpact"
-        case "":
-            hu.ImpactStatus = nil
-		default:
-			return nil, fmt.Errorf("unknown impact value: %s", *hu.ImpactStatus)
+		if len(*hu.ImpactStatus) == 0 {
+			hu.ImpactStatus = nil
+		} else {
+			impact, err := impactStatus(*hu.ImpactStatus)
+			if err != nil {
+				return nil, err
+			}
+			*hu.ImpactStatus = impact
 		}
 	}
 
@@ -395,6 +396,12 @@ type SearchQuery struct {
 	ScopeTo    int                   `json:"to,omitempty"`
 	ExtraData  []string              `json:"extraData,omitempty"`
 	IdOrName   string                `json:"idOrName,omitempty"`
+	// Filter contains an expression tree built with Eq, And, Or... which is inlined into the stage
+	Filter *Expr `json:"-"`
+	// SortFields allows sorting by multiple fields. Takes precedence over Sort
+	SortFields []map[string]string `json:"-"`
+	// Aggregation is inlined into the stage, use it with the name "aggregation"
+	Aggregation *Aggregation `json:"-"`
 }
 
 // Marshalling the SearchQuery
@@ -406,22 +413,63 @@ func (s *SearchQuery) MarshalJSON() ([]byte, error) {
 	)
 
 	if s.ScopeTo != 0 {
-		scopeTmpFrom := 0
-
-		scopeFrom = &scopeTmpFrom
+		scopeFrom = &s.ScopeFrom
 		scopeTo = &s.ScopeTo
+	}
 
+	var sortFields []map[string]string
+	if len(s.SortFields) != 0 {
+		sortFields = s.SortFields
+	} else if s.Sort != nil {
+		sortFields = s.Sort[:]
 	}
 
-	return json.Marshal(&struct {
-		ScopeFrom *int `json:"from,omitempty"`
-		ScopeTo   *int `json:"to,omitempty"`
+	stage, err := json.Marshal(&struct {
+		ScopeFrom *int                `json:"from,omitempty"`
+		ScopeTo   *int                `json:"to,omitempty"`
+		Sort      []map[string]string `json:"_fields,omitempty"`
 		*Alias
 	}{
 		ScopeFrom: scopeFrom,
 		ScopeTo:   scopeTo,
+		Sort:      sortFields,
 		Alias:     (*Alias)(s),
 	})
+	if err != nil || (s.Filter == nil && s.Aggregation == nil) {
+		return stage, err
+	}
+
+	// filter expressions and aggregations get inlined into the stage
+	var merged map[string]json.RawMessage
+	if err := json.Unmarshal(stage, &merged); err != nil {
+		return nil, err
+	}
+
+	var inline []interface{}
+	if s.Filter != nil {
+		inline = append(inline, s.Filter)
+	}
+	if s.Aggregation != nil {
+		inline = append(inline, s.Aggregation)
+	}
+
+	for _, object := range inline {
+		data, err := json.Marshal(object)
+		if err != nil {
+			return nil, err
+		}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, err
+		}
+
+		for key, value := range fields {
+			merged[key] = value
+		}
+	}
+
+	return json.Marshal(merged)
 }
 
 // A CaseStatusResponse is used for containing all possible case status options
@@ -493,13 +541,13 @@ type Scope struct {
 }
 
 // executeCaseSearchQuery is a helper function to do query related searches
-func (hive *Hivedata) executeCaseSearchQuery(query []byte) ([]HiveCaseResponse, error) {
+func (hive *Hivedata) executeCaseSearchQuery(ctx context.Context, query []byte) ([]HiveCaseResponse, error) {
 	url, err := url.JoinPath(hive.Url, "/api/v1/query")
 	if err != nil {
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, POST, query)
+	ret, err := hive.webRequest(ctx, url, POST, query)
 	if err != nil {
 		return nil, err
 	}
@@ -510,13 +558,13 @@ func (hive *Hivedata) executeCaseSearchQuery(query []byte) ([]HiveCaseResponse,
 }
 
 // executeCaseStatusQuery is a helper function to do query related searches
-func (hive *Hivedata) executeCaseStatusQuery(query []byte) ([]CaseStatusResponse, error) {
+func (hive *Hivedata) executeCaseStatusQuery(ctx context.Context, query []byte) ([]CaseStatusResponse, error) {
 	url, err := url.JoinPath(hive.Url, "/api/v1/query")
 	if err != nil {
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, POST, query)
+	ret, err := hive.webRequest(ctx, url, POST, query)
 	if err != nil {
 		return nil, err
 	}
@@ -528,6 +576,11 @@ func (hive *Hivedata) executeCaseStatusQuery(query []byte) ([]CaseStatusResponse
 
 // GetCaseStatusOptions returns all options that are able to be set on a case
 func (hive *Hivedata) GetCaseStatusOptions() ([]CaseStatusResponse, error) {
+	return hive.GetCaseStatusOptionsContext(context.Background())
+}
+
+// GetCaseStatusOptionsContext is like GetCaseStatusOptions but uses ctx for the request
+func (hive *Hivedata) GetCaseStatusOptionsContext(ctx context.Context) ([]CaseStatusResponse, error) {
 	query, err := hive.createSearchQuery(
 		SearchQuery{Name: "listCaseStatus"},
 		SearchQuery{Name: "sort", Sort: &[1]map[string]string{{"stage": "desc"}}},
@@ -536,48 +589,74 @@ func (hive *Hivedata) GetCaseStatusOptions() ([]CaseStatusResponse, error) {
 		return nil, err
 	}
 
-	return hive.executeCaseStatusQuery(query)
+	return hive.executeCaseStatusQuery(ctx, query)
 }
 
 // FindCaseByCustomField finds cases acoording to the query values on a specific custom field submitted
 func (hive *Hivedata) FindCaseByCustomField(queryfield string, queryvalue string) ([]HiveCaseResponse, error) {
-	query, err := hive.createSearchQuery(
+	return hive.FindCaseByCustomFieldContext(context.Background(), queryfield, queryvalue)
+}
+
+// FindCaseByCustomFieldContext is like FindCaseByCustomField but uses ctx for the request
+func (hive *Hivedata) FindCaseByCustomFieldContext(ctx context.Context, queryfield string, queryvalue string) ([]HiveCaseResponse, error) {
+	return NewQueryContext[HiveCaseResponse](ctx, hive,
 		SearchQuery{Name: "listCase"},
 		SearchQuery{Name: "filter", Eq: &Filter{Field: fmt.Sprintf("customFields.%s", strings.ToLower(queryfield)), Value: &queryvalue}},
 		SearchQuery{Name: "sort", Sort: &[1]map[string]string{{"_updatedAt": "desc"}}},
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	return hive.executeCaseSearchQuery(query)
+	).All()
 }
 
 // FindCase allows to search for self defined case queries
 func (hive *Hivedata) FindCase(searchQuery []SearchQuery) ([]HiveCaseResponse, error) {
+	return hive.FindCaseContext(context.Background(), searchQuery)
+}
+
+// FindCaseContext is like FindCase but uses ctx for the request
+func (hive *Hivedata) FindCaseContext(ctx context.Context, searchQuery []SearchQuery) ([]HiveCaseResponse, error) {
 
 	query, err := hive.createSearchQuery(searchQuery...)
 	if err != nil {
 		return nil, err
 	}
 
-	return hive.executeCaseSearchQuery(query)
+	return hive.executeCaseSearchQuery(ctx, query)
+}
+
+// CountCases returns the number of cases matching the filter stages
+// Without filters all cases are counted
+func (hive *Hivedata) CountCases(filters ...SearchQuery) (int, error) {
+	return hive.CountCasesContext(context.Background(), filters...)
+}
+
+// CountCasesContext is like CountCases but uses ctx for the request
+func (hive *Hivedata) CountCasesContext(ctx context.Context, filters ...SearchQuery) (int, error) {
+	return hive.CountContext(ctx, append([]SearchQuery{{Name: "listCase"}}, filters...))
 }
 
 func (hive *Hivedata) DeleteCase(caseId int) error {
+	return hive.DeleteCaseContext(context.Background(), caseId)
+}
+
+// DeleteCaseContext is like DeleteCase but uses ctx for the request
+func (hive *Hivedata) DeleteCaseContext(ctx context.Context, caseId int) error {
 	caseNumber := strconv.Itoa(caseId)
 	url, err := url.JoinPath(hive.Url, "/api/v1/case", caseNumber)
 	if err != nil {
 		return err
 	}
 
-	_, err = hive.webRequest(url, DELETE, nil)
+	_, err = hive.webRequest(ctx, url, DELETE, nil)
 	return err
 }
 
 // CreateCase is used to add a new case on thehive5
 // Returns HiveCase struct and response error
 func (hive *Hivedata) CreateCase(newCase *HiveCase) (*HiveCaseResponse, error) {
+	return hive.CreateCaseContext(context.Background(), newCase)
+}
+
+// CreateCaseContext is like CreateCase but uses ctx for the request
+func (hive *Hivedata) CreateCaseContext(ctx context.Context, newCase *HiveCase) (*HiveCaseResponse, error) {
 	url, err := url.JoinPath(hive.Url, "/api/v1/case")
 	if err != nil {
 		return nil, err
@@ -587,7 +666,7 @@ func (hive *Hivedata) CreateCase(newCase *HiveCase) (*HiveCaseResponse, error) {
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, POST, jsondata)
+	ret, err := hive.webRequest(ctx, url, POST, jsondata)
 	if err != nil {
 		return nil, err
 	}
@@ -604,6 +683,11 @@ func (hive *Hivedata) CreateCase(newCase *HiveCase) (*HiveCaseResponse, error) {
 // UpdateCase is used to update an existing case on thehive5
 // Only returns data if an error occurs
 func (hive *Hivedata) UpdateCase(idOrName int, updatedCase *HiveUpdateCase) error {
+	return hive.UpdateCaseContext(context.Background(), idOrName, updatedCase)
+}
+
+// UpdateCaseContext is like UpdateCase but uses ctx for the request
+func (hive *Hivedata) UpdateCaseContext(ctx context.Context, idOrName int, updatedCase *HiveUpdateCase) error {
 	url, err := url.JoinPath(hive.Url, "/api/v1/case", strconv.Itoa(idOrName))
 	if err != nil {
 		return err
@@ -614,24 +698,50 @@ func (hive *Hivedata) UpdateCase(idOrName int, updatedCase *HiveUpdateCase) erro
 		return err
 	}
 
-	_, err = hive.webRequest(url, PATCH, jsondata)
+	_, err = hive.webRequest(ctx, url, PATCH, jsondata)
+	return err
+}
+
+// patchCase updates single attributes of a case
+// Unlike UpdateCase it can send attributes which have no field in HiveUpdateCase, e.g. customFields.<name>
+func (hive *Hivedata) patchCase(ctx context.Context, caseId int, fields map[string]interface{}) error {
+	url, err := url.JoinPath(hive.Url, "/api/v1/case", strconv.Itoa(caseId))
+	if err != nil {
+		return err
+	}
+
+	jsondata, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	_, err = hive.webRequest(ctx, url, PATCH, jsondata)
 	return err
 }
 
 // CreateCaseFromAlert creates a new case from an existing alert
 // Returns newly created case
 func (hive *Hivedata) CreateCaseFromAlert(alertId string, alert *HiveCase) (*HiveCaseResponse, error) {
+	return hive.CreateCaseFromAlertContext(context.Background(), alertId, alert)
+}
+
+// CreateCaseFromAlertContext is like CreateCaseFromAlert but uses ctx for the request
+func (hive *Hivedata) CreateCaseFromAlertContext(ctx context.Context, alertId string, alert *HiveCase) (*HiveCaseResponse, error) {
 	url, err := url.JoinPath(hive.Url, "/api/v1/alert/", alertId, "/case")
 	if err != nil {
 		return nil, err
 	}
 
-	jsondata, err := json.Marshal(alert)
-	if err != nil {
-		return nil, err
+	// without a template the case gets its attributes from the alert
+	jsondata := []byte("{}")
+	if alert != nil {
+		jsondata, err = json.Marshal(alert)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	ret, err := hive.webRequest(url, POST, jsondata)
+	ret, err := hive.webRequest(ctx, url, POST, jsondata)
 	if err != nil {
 		return nil, err
 	}
@@ -644,15 +754,53 @@ func (hive *Hivedata) CreateCaseFromAlert(alertId string, alert *HiveCase) (*Hiv
 	return &parsedRet, err
 }
 
+// CreateCaseFromAlerts creates a new case from the first alert and merges the other alerts into it
+// template can be nil to take over the attributes of the first alert
+// Merging is done on a best effort basis, the result contains the outcome of every alert.
+// An error is only returned if the case couldn't be created. If the merge fails as a whole,
+// e.g. with 503 or a canceled context, the case is returned and every merged alert carries that error
+func (hive *Hivedata) CreateCaseFromAlerts(alertIds []string, template *HiveCase) (*HiveCaseResponse, BulkResult, error) {
+	return hive.CreateCaseFromAlertsContext(context.Background(), alertIds, template)
+}
+
+// CreateCaseFromAlertsContext is like CreateCaseFromAlerts but uses ctx for the requests
+func (hive *Hivedata) CreateCaseFromAlertsContext(ctx context.Context, alertIds []string, template *HiveCase) (*HiveCaseResponse, BulkResult, error) {
+	if len(alertIds) == 0 {
+		return nil, nil, fmt.Errorf("no alerts to create a case from")
+	}
+
+	created, err := hive.CreateCaseFromAlertContext(ctx, alertIds[0], template)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	merged, err := hive.MergeAlertsIntoCaseContext(ctx, created.Number, alertIds[1:])
+	if err != nil {
+		// the case exists, so the merge failures are reported per alert
+		merged = make(BulkResult, len(alertIds)-1)
+		for i, id := range alertIds[1:] {
+			merged[i] = BulkItemResult{Id: id, Err: err}
+		}
+	}
+
+	result := append(BulkResult{{Id: alertIds[0]}}, merged...)
+	return created, result, nil
+}
+
 // GetCase looks up a case by ID and returns it
 func (hive *Hivedata) GetCase(caseId int) (*HiveCaseResponse, error) {
+	return hive.GetCaseContext(context.Background(), caseId)
+}
+
+// GetCaseContext is like GetCase but uses ctx for the request
+func (hive *Hivedata) GetCaseContext(ctx context.Context, caseId int) (*HiveCaseResponse, error) {
 	caseNumber := strconv.Itoa(caseId)
 	url, err := url.JoinPath(hive.Url, "/api/v1/case/", caseNumber)
 	if err != nil {
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, GET, nil)
+	ret, err := hive.webRequest(ctx, url, GET, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -665,22 +813,29 @@ func (hive *Hivedata) GetCase(caseId int) (*HiveCaseResponse, error) {
 // GetCasesTimed takes time object to look back for a certain time and returns all the cases found
 // timeframe is always timeframe < xxx which means that everything since the timeframe will be returned
 func (hive *Hivedata) GetCasesTimed(timeframe time.Time) ([]HiveCaseResponse, error) {
+	return hive.GetCasesTimedContext(context.Background(), timeframe)
+}
+
+// GetCasesTimedContext is like GetCasesTimed but uses ctx for the request
+func (hive *Hivedata) GetCasesTimedContext(ctx context.Context, timeframe time.Time) ([]HiveCaseResponse, error) {
 	// hive expects a miliseconds time string
 	time := strconv.FormatInt(timeframe.UnixMilli(), 10)
 
-	query, err := hive.createSearchQuery(
+	return NewQueryContext[HiveCaseResponse](ctx, hive,
 		SearchQuery{Name: "listCase"},
-		SearchQuery{Name: "filter", Gte: &Filter{Field: "_updatedAt", Value: &time}})
-	if err != nil {
-		return nil, err
-	}
-
-	return hive.executeCaseSearchQuery(query)
+		SearchQuery{Name: "filter", Gte: &Filter{Field: "_updatedAt", Value: &time}},
+		SearchQuery{Name: "sort", Sort: &[1]map[string]string{{"_createdAt": "asc"}}},
+	).All()
 }
 
 // GetCaseAlerts returns all alerts associated with a case
 // It returns a AlertsResponse slice or an error
 func (hive *Hivedata) GetCaseAlerts(caseId int) ([]HiveAlertResponse, error) {
+	return hive.GetCaseAlertsContext(context.Background(), caseId)
+}
+
+// GetCaseAlertsContext is like GetCaseAlerts but uses ctx for the request
+func (hive *Hivedata) GetCaseAlertsContext(ctx context.Context, caseId int) ([]HiveAlertResponse, error) {
 	caseNumber := strconv.Itoa(caseId)
 	query, err := hive.createSearchQuery(
 		SearchQuery{Name: "getCase", IdOrName: caseNumber},
@@ -689,5 +844,211 @@ func (hive *Hivedata) GetCaseAlerts(caseId int) ([]HiveAlertResponse, error) {
 		return nil, err
 	}
 
-	return hive.executeAlertSearchQuery(query)
+	return hive.executeAlertSearchQuery(ctx, query)
+}
+
+// MergeCases merges the cases into a new case and returns it
+// thehive5 moves the tasks, observables and alerts into the new case and deletes the merged cases
+func (hive *Hivedata) MergeCases(caseIds []int) (*HiveCaseResponse, error) {
+	return hive.MergeCasesContext(context.Background(), caseIds)
+}
+
+// MergeCasesContext is like MergeCases but uses ctx for the request
+func (hive *Hivedata) MergeCasesContext(ctx context.Context, caseIds []int) (*HiveCaseResponse, error) {
+	if len(caseIds) < 2 {
+		return nil, fmt.Errorf("at least two cases are required for a merge, got %d", len(caseIds))
+	}
+
+	ids := make([]string, len(caseIds))
+	for i, id := range caseIds {
+		ids[i] = strconv.Itoa(id)
+	}
+
+	url, err := url.JoinPath(hive.Url, "/api/v1/case/_merge", strings.Join(ids, ","))
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := hive.webRequest(ctx, url, POST, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedRet := HiveCaseResponse{}
+	err = json.Unmarshal(ret, &parsedRet)
+	if err != nil {
+		return nil, err
+	}
+	return &parsedRet, nil
+}
+
+// A LinkedCase is a case sharing observables with another case
+type LinkedCase struct {
+	Case HiveCaseResponse
+	// LinkedWith contains the shared observables of the linked case
+	LinkedWith []ObservableResponse
+	LinksCount int
+}
+
+// Unmarshal the case attributes and the link details which thehive5 returns in the same object
+func (lc *LinkedCase) UnmarshalJSON(data []byte) error {
+	var links struct {
+		LinkedWith []ObservableResponse `json:"linkedWith"`
+		LinksCount int                  `json:"linksCount"`
+	}
+	err := json.Unmarshal(data, &links)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(data, &lc.Case)
+	if err != nil {
+		return err
+	}
+
+	lc.LinkedWith = links.LinkedWith
+	lc.LinksCount = links.LinksCount
+	return nil
+}
+
+// GetLinkedCases returns the cases sharing observables with a case
+func (hive *Hivedata) GetLinkedCases(caseId int) ([]LinkedCase, error) {
+	return hive.GetLinkedCasesContext(context.Background(), caseId)
+}
+
+// GetLinkedCasesContext is like GetLinkedCases but uses ctx for the request
+func (hive *Hivedata) GetLinkedCasesContext(ctx context.Context, caseId int) ([]LinkedCase, error) {
+	query, err := hive.createSearchQuery(
+		SearchQuery{Name: "getCase", IdOrName: strconv.Itoa(caseId)},
+		SearchQuery{Name: "linkedCases"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := url.JoinPath(hive.Url, "/api/v1/query")
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := hive.webRequest(ctx, url, POST, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsedRet []LinkedCase
+	err = json.Unmarshal(ret, &parsedRet)
+	return parsedRet, err
+}
+
+// Constants for the impact of a closed case
+const (
+	ImpactStatusWithImpact    = "WithImpact"
+	ImpactStatusNoImpact      = "NoImpact"
+	ImpactStatusNotApplicable = "NotApplicable"
+)
+
+// impactStatus returns the ImpactStatus constant matching v regardless of its case
+func impactStatus(v string) (string, error) {
+	switch strings.ToLower(v) {
+	case "withimpact":
+		return ImpactStatusWithImpact, nil
+	case "noimpact":
+		return ImpactStatusNoImpact, nil
+	case "notapplicable":
+		return ImpactStatusNotApplicable, nil
+	default:
+		return "", fmt.Errorf("unknown impact value: %s. Allowed: %s,%s,%s", v, ImpactStatusWithImpact, ImpactStatusNoImpact, ImpactStatusNotApplicable)
+	}
+}
+
+// CaseStageClosed is the stage of all statuses which close a case
+const CaseStageClosed = "Closed"
+
+// ErrOpenMandatoryTasks is returned by CloseCase if mandatory tasks aren't completed yet
+var ErrOpenMandatoryTasks = errors.New("thehive5: mandatory tasks are still open")
+
+// A CaseClosure contains the information needed to close a case
+type CaseClosure struct {
+	// Resolution is a status of the closed stage, e.g. TruePositive or FalsePositive
+	Resolution string
+	// Impact is one of the ImpactStatus constants, the case of the letters doesn't matter
+	Impact  string
+	Summary string
+	// RequireMandatoryTasks refuses to close the case while mandatory tasks are waiting or in progress
+	RequireMandatoryTasks bool
+}
+
+// CloseCase closes a case after checking the resolution against the closed statuses of GetCaseStatusOptions
+// The summary and impact are checked before any request is sent
+func (hive *Hivedata) CloseCase(caseId int, closure CaseClosure) error {
+	return hive.CloseCaseContext(context.Background(), caseId, closure)
+}
+
+// CloseCaseContext is like CloseCase but uses ctx for the requests
+func (hive *Hivedata) CloseCaseContext(ctx context.Context, caseId int, closure CaseClosure) error {
+	if len(closure.Summary) == 0 {
+		return fmt.Errorf("a summary is required to close a case")
+	}
+
+	if len(closure.Impact) == 0 {
+		return fmt.Errorf("an impact is required to close a case")
+	}
+	impact, err := impactStatus(closure.Impact)
+	if err != nil {
+		return fmt.Errorf("invalid impact %q, valid impacts are %s, %s, %s", closure.Impact, ImpactStatusWithImpact, ImpactStatusNoImpact, ImpactStatusNotApplicable)
+	}
+
+	options, err := hive.GetCaseStatusOptionsContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	valid := false
+	var closed []string
+	for _, option := range options {
+		if option.Stage != CaseStageClosed {
+			continue
+		}
+		closed = append(closed, option.Value)
+		if option.Value == closure.Resolution {
+			valid = true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("invalid resolution %q, valid resolutions are %s", closure.Resolution, strings.Join(closed, ", "))
+	}
+
+	if closure.RequireMandatoryTasks {
+		tasks, err := hive.GetCaseTasksContext(ctx, caseId)
+		if err != nil {
+			return err
+		}
+
+		var open []string
+		for _, task := range tasks {
+			if task.Mandatory && task.Status != "Completed" && task.Status != "Cancel" {
+				open = append(open, task.Title)
+			}
+		}
+		if len(open) != 0 {
+			return fmt.Errorf("%w: %s", ErrOpenMandatoryTasks, strings.Join(open, ", "))
+		}
+	}
+
+	return hive.UpdateCaseContext(ctx, caseId, &HiveUpdateCase{
+		Status:       closure.Resolution,
+		Summary:      &closure.Summary,
+		ImpactStatus: &impact,
+	})
+}
+
+// ReopenCase sets a closed case back to InProgress
+func (hive *Hivedata) ReopenCase(caseId int) error {
+	return hive.ReopenCaseContext(context.Background(), caseId)
+}
+
+// ReopenCaseContext is like ReopenCase but uses ctx for the request
+func (hive *Hivedata) ReopenCaseContext(ctx context.Context, caseId int) error {
+	return hive.UpdateCaseContext(ctx, caseId, &HiveUpdateCase{Status: "InProgress"})
 }
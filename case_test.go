@@ -0,0 +1,201 @@
+package thehive5_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	thehive5 "github.com/b401/goHive5"
+	"github.com/b401/goHive5/thehive5test"
+)
+
+func TestCloseCase(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	hive := srv.Hive()
+
+	tasks := []thehive5.CaseTask{{Title: "contain", Mandatory: true}, {Title: "document"}}
+	created, err := hive.CreateCase(&thehive5.HiveCase{Title: "case", Description: "case", Tasks: &tasks})
+	if err != nil {
+		t.Fatalf("CreateCase: %v", err)
+	}
+
+	// the summary and impact are checked without sending a request
+	for _, tt := range []struct {
+		closure thehive5.CaseClosure
+		want    string
+	}{
+		{thehive5.CaseClosure{Resolution: "TruePositive", Impact: thehive5.ImpactStatusNoImpact}, "summary is required"},
+		{thehive5.CaseClosure{Resolution: "TruePositive", Summary: "done"}, "impact is required"},
+		{thehive5.CaseClosure{Resolution: "TruePositive", Impact: "minor", Summary: "done"}, `invalid impact "minor"`},
+	} {
+		srv.ClearRequests()
+		err := hive.CloseCase(created.Number, tt.closure)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("CloseCase(%+v) = %v, want %q", tt.closure, err, tt.want)
+		}
+		if n := len(srv.Requests()); n != 0 {
+			t.Fatalf("CloseCase(%+v) sent %d requests", tt.closure, n)
+		}
+	}
+
+	err = hive.CloseCase(created.Number, thehive5.CaseClosure{Resolution: "Solved", Impact: thehive5.ImpactStatusNoImpact, Summary: "done"})
+	if err == nil || !strings.Contains(err.Error(), `invalid resolution "Solved"`) || !strings.Contains(err.Error(), "TruePositive") {
+		t.Fatalf("CloseCase with an unknown resolution = %v", err)
+	}
+
+	// the impact is sent as the constant whatever the case of its letters
+	closure := thehive5.CaseClosure{Resolution: "TruePositive", Impact: "withimpact", Summary: "contained", RequireMandatoryTasks: true}
+	err = hive.CloseCase(created.Number, closure)
+	if !errors.Is(err, thehive5.ErrOpenMandatoryTasks) || !strings.Contains(err.Error(), "contain") || strings.Contains(err.Error(), "document") {
+		t.Fatalf("CloseCase with an open mandatory task = %v", err)
+	}
+	if got, _ := hive.GetCase(created.Number); got.Status != "New" {
+		t.Fatalf("status = %s after a refused closure", got.Status)
+	}
+
+	caseTasks, err := hive.GetCaseTasks(created.Number)
+	if err != nil {
+		t.Fatalf("GetCaseTasks: %v", err)
+	}
+	for _, task := range caseTasks {
+		if task.Mandatory {
+			if err := hive.UpdateTask(task.Id, &thehive5.CaseTask{Title: task.Title, Status: "Completed"}); err != nil {
+				t.Fatalf("UpdateTask: %v", err)
+			}
+		}
+	}
+
+	if err := hive.CloseCase(created.Number, closure); err != nil {
+		t.Fatalf("CloseCase: %v", err)
+	}
+	got, err := hive.GetCase(created.Number)
+	if err != nil {
+		t.Fatalf("GetCase: %v", err)
+	}
+	if got.Status != "TruePositive" || got.Stage != thehive5.CaseStageClosed || got.ImpactStatus != thehive5.ImpactStatusWithImpact || got.Summary != "contained" {
+		t.Fatalf("closed case: status %s stage %s impact %s summary %q", got.Status, got.Stage, got.ImpactStatus, got.Summary)
+	}
+}
+
+func TestImpactStatus(t *testing.T) {
+	for _, tt := range []struct {
+		impact string
+		want   string
+	}{
+		{"WithImpact", thehive5.ImpactStatusWithImpact},
+		{"noimpact", thehive5.ImpactStatusNoImpact},
+		{"NOTAPPLICABLE", thehive5.ImpactStatusNotApplicable},
+		{"minor", ""},
+	} {
+		impact := tt.impact
+		update := &thehive5.HiveUpdateCase{ImpactStatus: &impact}
+		validateErr := update.Validate()
+		got, err := json.Marshal(update)
+		if len(tt.want) == 0 {
+			if err == nil || validateErr == nil {
+				t.Fatalf("impact %q: MarshalJSON = %s, %v, Validate = %v", tt.impact, got, err, validateErr)
+			}
+			continue
+		}
+		if err != nil || validateErr != nil || !strings.Contains(string(got), `"impactStatus":"`+tt.want+`"`) {
+			t.Fatalf("impact %q: MarshalJSON = %s, %v, Validate = %v", tt.impact, got, err, validateErr)
+		}
+	}
+}
+
+func TestCreateCaseFromAlerts(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	hive := srv.Hive()
+
+	var ids []string
+	for _, ref := range []string{"1", "2", "3", "4", "5"} {
+		alert, err := hive.CreateAlert(&thehive5.HiveAlert{Type: "external", Source: "test", SourceRef: ref, Title: "alert " + ref, Description: "alert"})
+		if err != nil {
+			t.Fatalf("CreateAlert: %v", err)
+		}
+		ids = append(ids, alert.Id)
+	}
+
+	// an unknown alert is reported on its own, the case is created with the other alerts
+	created, result, err := hive.CreateCaseFromAlerts([]string{ids[0], ids[1], "~unknown", ids[2]}, nil)
+	if err != nil {
+		t.Fatalf("CreateCaseFromAlerts: %v", err)
+	}
+	if created.Title != "alert 1" || len(result) != 4 {
+		t.Fatalf("CreateCaseFromAlerts = %+v, %v", created, result)
+	}
+	failed := result.Failed()
+	if len(failed) != 1 || failed[0].Id != "~unknown" || !errors.Is(failed[0].Err, thehive5.ErrNotFound) {
+		t.Fatalf("failed alerts = %v", failed)
+	}
+	alerts, err := hive.GetCaseAlerts(created.Number)
+	if err != nil || len(alerts) != 3 {
+		t.Fatalf("GetCaseAlerts = %d alerts, %v, want 3", len(alerts), err)
+	}
+
+	// the merge fails as a whole, the case exists and every merged alert carries the error
+	srv.Intercept = func(req thehive5test.Request) error {
+		if strings.HasSuffix(req.Path, "/merge/_bulk") {
+			return thehive5test.Error(http.StatusServiceUnavailable, "Unavailable", "try again")
+		}
+		return nil
+	}
+	created, result, err = hive.CreateCaseFromAlerts(ids[3:], nil)
+	if err != nil {
+		t.Fatalf("CreateCaseFromAlerts with a failing merge: %v", err)
+	}
+	if result[0].Id != ids[3] || result[0].Err != nil || result[1].Id != ids[4] || !thehive5.IsRetryable(result[1].Err) {
+		t.Fatalf("CreateCaseFromAlerts with a failing merge = %v", result)
+	}
+	alerts, err = hive.GetCaseAlerts(created.Number)
+	if err != nil || len(alerts) != 1 || alerts[0].Id != ids[3] {
+		t.Fatalf("GetCaseAlerts = %v, %v, want only the first alert", alerts, err)
+	}
+
+	// without a case there is nothing to report per alert
+	if _, result, err := hive.CreateCaseFromAlerts([]string{"~unknown", ids[0]}, nil); !errors.Is(err, thehive5.ErrNotFound) || result != nil {
+		t.Fatalf("CreateCaseFromAlerts with an unknown first alert = %v, %v", result, err)
+	}
+	if _, _, err := hive.CreateCaseFromAlerts(nil, nil); err == nil {
+		t.Fatal("CreateCaseFromAlerts without alerts succeeded")
+	}
+}
+
+func TestMergeAndLinkedCases(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	hive := srv.Hive()
+	cases := createCases(t, hive, "low", "high", "medium")
+	shared := thehive5.Observable{DataType: "ip", Data: "10.0.0.1"}
+	for _, c := range cases {
+		if err := hive.AddCaseObservable(c.Number, &shared); err != nil {
+			t.Fatalf("AddCaseObservable: %v", err)
+		}
+	}
+
+	merged, err := hive.MergeCases([]int{cases[0].Number, cases[1].Number})
+	if err != nil {
+		t.Fatalf("MergeCases: %v", err)
+	}
+	if merged.Title != "case 0 / case 1" {
+		t.Fatalf("merged title = %q", merged.Title)
+	}
+	if _, err := hive.GetCase(cases[0].Number); !errors.Is(err, thehive5.ErrNotFound) {
+		t.Fatalf("merged case still exists: %v", err)
+	}
+	if n := len(srv.Objects(thehive5test.KindCase)); n != 2 {
+		t.Fatalf("%d cases after merge, want 2", n)
+	}
+
+	linked, err := hive.GetLinkedCases(merged.Number)
+	if err != nil {
+		t.Fatalf("GetLinkedCases: %v", err)
+	}
+	if len(linked) != 1 || linked[0].Case.Number != cases[2].Number || linked[0].LinksCount != 1 {
+		t.Fatalf("GetLinkedCases = %+v", linked)
+	}
+}
@@ -5,6 +5,7 @@ https://www.strangebee.com/thehive/
 package thehive5
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"strconv"
@@ -83,13 +84,13 @@ func (c *CommentResponse) UnmarshalJSON(data []byte) error {
 }
 
 // executeCommentSearchQuery is a helper function to do query related searches
-func (hive *Hivedata) executeCommentSearchQuery(query []byte) ([]CommentResponse, error) {
+func (hive *Hivedata) executeCommentSearchQuery(ctx context.Context, query []byte) ([]CommentResponse, error) {
 	url, err := url.JoinPath(hive.Url, "/api/v1/query")
 	if err != nil {
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, POST, query)
+	ret, err := hive.webRequest(ctx, url, POST, query)
 	if err != nil {
 		return nil, err
 	}
@@ -102,6 +103,11 @@ func (hive *Hivedata) executeCommentSearchQuery(query []byte) ([]CommentResponse
 // GetAlertComments returns all comments associated with an alert
 // It returns a comment slice or an error
 func (hive *Hivedata) GetAlertComments(alertId string) ([]CommentResponse, error) {
+	return hive.GetAlertCommentsContext(context.Background(), alertId)
+}
+
+// GetAlertCommentsContext is like GetAlertComments but uses ctx for the request
+func (hive *Hivedata) GetAlertCommentsContext(ctx context.Context, alertId string) ([]CommentResponse, error) {
 	query, err := hive.createSearchQuery(
 		SearchQuery{Name: "getAlert", IdOrName: alertId},
 		SearchQuery{Name: "comments"},
@@ -110,12 +116,17 @@ func (hive *Hivedata) GetAlertComments(alertId string) ([]CommentResponse, error
 		return nil, err
 	}
 
-	return hive.executeCommentSearchQuery(query)
+	return hive.executeCommentSearchQuery(ctx, query)
 }
 
 // AddAlertComment adds a comment to an existing alert
 // Returns the created comment as Comment or an error
 func (hive *Hivedata) AddAlertComment(alertId string, comment *Comment) (*CommentResponse, error) {
+	return hive.AddAlertCommentContext(context.Background(), alertId, comment)
+}
+
+// AddAlertCommentContext is like AddAlertComment but uses ctx for the request
+func (hive *Hivedata) AddAlertCommentContext(ctx context.Context, alertId string, comment *Comment) (*CommentResponse, error) {
 	url, err := url.JoinPath(hive.Url, "/api/v1/alert/", alertId, "/comment")
 	if err != nil {
 		return nil, err
@@ -126,7 +137,7 @@ func (hive *Hivedata) AddAlertComment(alertId string, comment *Comment) (*Commen
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, POST, jsonValue)
+	ret, err := hive.webRequest(ctx, url, POST, jsonValue)
 	if err != nil {
 		return nil, err
 	}
@@ -139,6 +150,11 @@ func (hive *Hivedata) AddAlertComment(alertId string, comment *Comment) (*Commen
 // AddCaseComment adds a comment to an existing case
 // Returns the created comment as Comment or an error
 func (hive *Hivedata) AddCaseComment(caseId int, comment *Comment) (*CommentResponse, error) {
+	return hive.AddCaseCommentContext(context.Background(), caseId, comment)
+}
+
+// AddCaseCommentContext is like AddCaseComment but uses ctx for the request
+func (hive *Hivedata) AddCaseCommentContext(ctx context.Context, caseId int, comment *Comment) (*CommentResponse, error) {
 	caseNumber := strconv.Itoa(caseId)
 	url, err := url.JoinPath(hive.Url, "/api/v1/case/", caseNumber, "/comment")
 	if err != nil {
@@ -150,7 +166,7 @@ func (hive *Hivedata) AddCaseComment(caseId int, comment *Comment) (*CommentResp
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, POST, jsonValue)
+	ret, err := hive.webRequest(ctx, url, POST, jsonValue)
 	if err != nil {
 		return nil, err
 	}
@@ -163,6 +179,11 @@ func (hive *Hivedata) AddCaseComment(caseId int, comment *Comment) (*CommentResp
 // GetCaseComments returns all comments associated with a case
 // It returns a comment slice or an error
 func (hive *Hivedata) GetCaseComments(caseId int) ([]CommentResponse, error) {
+	return hive.GetCaseCommentsContext(context.Background(), caseId)
+}
+
+// GetCaseCommentsContext is like GetCaseComments but uses ctx for the request
+func (hive *Hivedata) GetCaseCommentsContext(ctx context.Context, caseId int) ([]CommentResponse, error) {
 	caseNumber := strconv.Itoa(caseId)
 	query, err := hive.createSearchQuery(
 		SearchQuery{Name: "getCase", IdOrName: caseNumber},
@@ -172,11 +193,16 @@ func (hive *Hivedata) GetCaseComments(caseId int) ([]CommentResponse, error) {
 		return nil, err
 	}
 
-	return hive.executeCommentSearchQuery(query)
+	return hive.executeCommentSearchQuery(ctx, query)
 }
 
 // GetCaseCommentsTimed takes a time object to look back and return all comments for a specific case in that frame.
 func (hive *Hivedata) GetCaseCommentsTimed(caseId int, timeframe time.Time) ([]CommentResponse, error) {
+	return hive.GetCaseCommentsTimedContext(context.Background(), caseId, timeframe)
+}
+
+// GetCaseCommentsTimedContext is like GetCaseCommentsTimed but uses ctx for the request
+func (hive *Hivedata) GetCaseCommentsTimedContext(ctx context.Context, caseId int, timeframe time.Time) ([]CommentResponse, error) {
 	caseNumber := strconv.Itoa(caseId)
 
 	query, err := hive.createSearchQuery(
@@ -188,5 +214,5 @@ func (hive *Hivedata) GetCaseCommentsTimed(caseId int, timeframe time.Time) ([]C
 		return nil, err
 	}
 
-	return hive.executeCommentSearchQuery(query)
+	return hive.executeCommentSearchQuery(ctx, query)
 }
@@ -0,0 +1,382 @@
+/*
+thehive5 implements functionality to interact with the most recent version of thehive.
+https://www.strangebee.com/thehive/
+*/
+package thehive5
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// setCustomField returns the custom field name of fields with the new value and reports if the value changed
+// The type of an existing field is kept and the value has to match it, the type of a new field is detected
+func setCustomField(fields []CustomField, name string, value interface{}) (CustomField, bool, error) {
+	name = strings.ToLower(name)
+
+	for _, field := range fields {
+		if strings.ToLower(field.Name) != name {
+			continue
+		}
+
+		changed := field
+		changed.Name = name
+		changed.Value = value
+		// nil clears the value
+		if len(field.Type) != 0 && value != nil && !customFieldValueMatches(field.Type, value) {
+			return field, false, fmt.Errorf("value %v is not a valid %s for custom field %s", value, field.Type, name)
+		}
+
+		equal, err := sameCustomFieldValue(field, changed)
+		if err != nil || equal {
+			return field, false, err
+		}
+		return changed, true, nil
+	}
+
+	field := CustomField{Name: name, Value: value}
+	detected, err := detectCustomFieldType(value)
+	if err != nil {
+		return field, false, err
+	}
+	field.Type = *detected
+	return field, true, nil
+}
+
+// customFieldPatch returns the body updating only the given custom field
+// thehive5 sets a single custom field with the attribute customFields.<name>
+func customFieldPatch(field CustomField) (map[string]interface{}, error) {
+	value, err := customFieldValue(field.Type, field.Value)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"customFields." + field.Name: value}, nil
+}
+
+// removeCustomField removes a custom field from fields and reports if it was set
+func removeCustomField(fields []CustomField, name string) ([]CustomField, bool) {
+	name = strings.ToLower(name)
+
+	var updated []CustomField
+	for _, field := range fields {
+		if strings.ToLower(field.Name) != name {
+			updated = append(updated, field)
+		}
+	}
+
+	if len(updated) == len(fields) {
+		return fields, false
+	}
+	if updated == nil {
+		updated = []CustomField{}
+	}
+	return updated, true
+}
+
+// sameCustomFieldValue compares the values as they would be sent to thehive5
+func sameCustomFieldValue(a CustomField, b CustomField) (bool, error) {
+	aType, bType := a.Type, b.Type
+	if len(aType) == 0 {
+		aType = bType
+	}
+	if len(bType) == 0 {
+		bType = aType
+	}
+
+	aValue, err := customFieldValue(aType, a.Value)
+	if err != nil {
+		return false, err
+	}
+	bValue, err := customFieldValue(bType, b.Value)
+	if err != nil {
+		return false, err
+	}
+
+	aJson, err := json.Marshal(aValue)
+	if err != nil {
+		return false, err
+	}
+	bJson, err := json.Marshal(bValue)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(aJson, bJson), nil
+}
+
+// GetCaseCustomFields returns the custom fields set on a case
+func (hive *Hivedata) GetCaseCustomFields(caseId int) ([]CustomField, error) {
+	return hive.GetCaseCustomFieldsContext(context.Background(), caseId)
+}
+
+// GetCaseCustomFieldsContext is like GetCaseCustomFields but uses ctx for the request
+func (hive *Hivedata) GetCaseCustomFieldsContext(ctx context.Context, caseId int) ([]CustomField, error) {
+	hiveCase, err := hive.GetCaseContext(ctx, caseId)
+	if err != nil {
+		return nil, err
+	}
+	return hiveCase.CustomFields, nil
+}
+
+// SetCaseCustomField sets a single custom field of a case, only this field is sent to thehive5
+// The value of an existing field has to match its type, the type of a new field is detected from value.
+// Nothing is sent if the value didn't change
+func (hive *Hivedata) SetCaseCustomField(caseId int, name string, value interface{}) error {
+	return hive.SetCaseCustomFieldContext(context.Background(), caseId, name, value)
+}
+
+// SetCaseCustomFieldContext is like SetCaseCustomField but uses ctx for the requests
+func (hive *Hivedata) SetCaseCustomFieldContext(ctx context.Context, caseId int, name string, value interface{}) error {
+	fields, err := hive.GetCaseCustomFieldsContext(ctx, caseId)
+	if err != nil {
+		return err
+	}
+
+	field, changed, err := setCustomField(fields, name, value)
+	if err != nil || !changed {
+		return err
+	}
+
+	body, err := customFieldPatch(field)
+	if err != nil {
+		return err
+	}
+	return hive.patchCase(ctx, caseId, body)
+}
+
+// RemoveCaseCustomField removes a single custom field from a case and keeps all others
+// thehive5 can't remove a single custom field, so the remaining fields are read and written back as a whole.
+// Custom fields changed by someone else between reading and writing them are overwritten
+func (hive *Hivedata) RemoveCaseCustomField(caseId int, name string) error {
+	return hive.RemoveCaseCustomFieldContext(context.Background(), caseId, name)
+}
+
+// RemoveCaseCustomFieldContext is like RemoveCaseCustomField but uses ctx for the requests
+func (hive *Hivedata) RemoveCaseCustomFieldContext(ctx context.Context, caseId int, name string) error {
+	fields, err := hive.GetCaseCustomFieldsContext(ctx, caseId)
+	if err != nil {
+		return err
+	}
+
+	fields, changed := removeCustomField(fields, name)
+	if !changed {
+		return nil
+	}
+
+	return hive.UpdateCaseContext(ctx, caseId, &HiveUpdateCase{CustomFields: &fields})
+}
+
+// GetAlertCustomFields returns the custom fields set on an alert
+func (hive *Hivedata) GetAlertCustomFields(alertId string) ([]CustomField, error) {
+	return hive.GetAlertCustomFieldsContext(context.Background(), alertId)
+}
+
+// GetAlertCustomFieldsContext is like GetAlertCustomFields but uses ctx for the request
+func (hive *Hivedata) GetAlertCustomFieldsContext(ctx context.Context, alertId string) ([]CustomField, error) {
+	alert, err := hive.GetAlertContext(ctx, alertId)
+	if err != nil {
+		return nil, err
+	}
+	return alert.CustomFields, nil
+}
+
+// SetAlertCustomField sets a single custom field of an alert, only this field is sent to thehive5
+// The value of an existing field has to match its type, the type of a new field is detected from value.
+// Nothing is sent if the value didn't change
+func (hive *Hivedata) SetAlertCustomField(alertId string, name string, value interface{}) error {
+	return hive.SetAlertCustomFieldContext(context.Background(), alertId, name, value)
+}
+
+// SetAlertCustomFieldContext is like SetAlertCustomField but uses ctx for the requests
+func (hive *Hivedata) SetAlertCustomFieldContext(ctx context.Context, alertId string, name string, value interface{}) error {
+	fields, err := hive.GetAlertCustomFieldsContext(ctx, alertId)
+	if err != nil {
+		return err
+	}
+
+	field, changed, err := setCustomField(fields, name, value)
+	if err != nil || !changed {
+		return err
+	}
+
+	body, err := customFieldPatch(field)
+	if err != nil {
+		return err
+	}
+	return hive.patchAlert(ctx, alertId, body)
+}
+
+// RemoveAlertCustomField removes a single custom field from an alert and keeps all others
+// thehive5 can't remove a single custom field, so the remaining fields are read and written back as a whole.
+// Custom fields changed by someone else between reading and writing them are overwritten
+func (hive *Hivedata) RemoveAlertCustomField(alertId string, name string) error {
+	return hive.RemoveAlertCustomFieldContext(context.Background(), alertId, name)
+}
+
+// RemoveAlertCustomFieldContext is like RemoveAlertCustomField but uses ctx for the requests
+func (hive *Hivedata) RemoveAlertCustomFieldContext(ctx context.Context, alertId string, name string) error {
+	fields, err := hive.GetAlertCustomFieldsContext(ctx, alertId)
+	if err != nil {
+		return err
+	}
+
+	fields, changed := removeCustomField(fields, name)
+	if !changed {
+		return nil
+	}
+
+	// HiveUpdateAlert can't be used as it omits an empty list and overwrites the alert type
+	return hive.patchAlert(ctx, alertId, map[string]interface{}{"customFields": fields})
+}
+
+// customFieldTypes are the types a custom field definition can have on thehive5
+var customFieldTypes = []string{"string", "integer", "float", "boolean", "date", "url"}
+
+// A CustomFieldDefinition declares a custom field on thehive5
+// Options restricts the values of the field, they must match its type (e.g. strings for a string field)
+type CustomFieldDefinition struct {
+	Name        string        `json:"name"`
+	DisplayName string        `json:"displayName,omitempty"`
+	Group       string        `json:"group,omitempty"`
+	Description string        `json:"description"`
+	Type        string        `json:"type"`
+	Options     []interface{} `json:"options,omitempty"`
+	Mandatory   bool          `json:"mandatory"`
+}
+
+// A CustomFieldDefinitionUpdate contains the attributes of a definition to overwrite
+// The name and type of a custom field can't be changed
+type CustomFieldDefinitionUpdate struct {
+	DisplayName *string        `json:"displayName,omitempty"`
+	Group       *string        `json:"group,omitempty"`
+	Description *string        `json:"description,omitempty"`
+	Options     *[]interface{} `json:"options,omitempty"`
+	Mandatory   *bool          `json:"mandatory,omitempty"`
+}
+
+// A CustomFieldDefinitionResponse is a custom field declared on thehive5
+// Options keeps the values as returned by thehive5, e.g. numbers for integer fields
+type CustomFieldDefinitionResponse struct {
+	CustomFieldResponse
+	Options []interface{} `json:"options,omitempty"`
+}
+
+// Unmarshal thehive5 returned values into the CustomFieldDefinitionResponse struct. Making sure that int64 gets converted into time.Time
+func (c *CustomFieldDefinitionResponse) UnmarshalJSON(data []byte) error {
+	// the options of the definition hide the options of the embedded shadow
+	shadow := new(struct {
+		shadowCustomFieldResponse
+		Options []interface{} `json:"options,omitempty"`
+	})
+	err := json.Unmarshal(data, &shadow)
+	if err != nil {
+		return err
+	}
+
+	shadow.copyTo(&c.CustomFieldResponse)
+	c.Options = shadow.Options
+
+	return nil
+}
+
+// ListCustomFieldDefinitions returns all custom fields declared on thehive5
+func (hive *Hivedata) ListCustomFieldDefinitions() ([]CustomFieldDefinitionResponse, error) {
+	return hive.ListCustomFieldDefinitionsContext(context.Background())
+}
+
+// ListCustomFieldDefinitionsContext is like ListCustomFieldDefinitions but uses ctx for the request
+func (hive *Hivedata) ListCustomFieldDefinitionsContext(ctx context.Context) ([]CustomFieldDefinitionResponse, error) {
+	url, err := url.JoinPath(hive.Url, "/api/v1/customField")
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := hive.webRequest(ctx, url, GET, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsedRet []CustomFieldDefinitionResponse
+	err = json.Unmarshal(ret, &parsedRet)
+	return parsedRet, err
+}
+
+// CreateCustomFieldDefinition declares a new custom field on thehive5
+// The name is converted to lowercase, the type must be one of string, integer, float, boolean, date or url
+func (hive *Hivedata) CreateCustomFieldDefinition(definition CustomFieldDefinition) (*CustomFieldDefinitionResponse, error) {
+	return hive.CreateCustomFieldDefinitionContext(context.Background(), definition)
+}
+
+// CreateCustomFieldDefinitionContext is like CreateCustomFieldDefinition but uses ctx for the request
+func (hive *Hivedata) CreateCustomFieldDefinitionContext(ctx context.Context, definition CustomFieldDefinition) (*CustomFieldDefinitionResponse, error) {
+	if len(definition.Name) == 0 {
+		return nil, fmt.Errorf("custom field name is required")
+	}
+	if !slices.Contains(customFieldTypes, definition.Type) {
+		return nil, fmt.Errorf("invalid custom field type %q for %s", definition.Type, definition.Name)
+	}
+	definition.Name = strings.ToLower(definition.Name)
+	if len(definition.DisplayName) == 0 {
+		definition.DisplayName = definition.Name
+	}
+
+	url, err := url.JoinPath(hive.Url, "/api/v1/customField")
+	if err != nil {
+		return nil, err
+	}
+
+	jsonrequest, err := json.Marshal(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := hive.webRequest(ctx, url, POST, jsonrequest)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedRet := new(CustomFieldDefinitionResponse)
+	err = json.Unmarshal(ret, parsedRet)
+	return parsedRet, err
+}
+
+// UpdateCustomFieldDefinition changes the custom field with the given id or name
+func (hive *Hivedata) UpdateCustomFieldDefinition(idOrName string, update CustomFieldDefinitionUpdate) error {
+	return hive.UpdateCustomFieldDefinitionContext(context.Background(), idOrName, update)
+}
+
+// UpdateCustomFieldDefinitionContext is like UpdateCustomFieldDefinition but uses ctx for the request
+func (hive *Hivedata) UpdateCustomFieldDefinitionContext(ctx context.Context, idOrName string, update CustomFieldDefinitionUpdate) error {
+	url, err := url.JoinPath(hive.Url, "/api/v1/customField/", idOrName)
+	if err != nil {
+		return err
+	}
+
+	jsonrequest, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+
+	_, err = hive.webRequest(ctx, url, PATCH, jsonrequest)
+	return err
+}
+
+// DeleteCustomFieldDefinition removes the custom field with the given id or name from thehive5
+func (hive *Hivedata) DeleteCustomFieldDefinition(idOrName string) error {
+	return hive.DeleteCustomFieldDefinitionContext(context.Background(), idOrName)
+}
+
+// DeleteCustomFieldDefinitionContext is like DeleteCustomFieldDefinition but uses ctx for the request
+func (hive *Hivedata) DeleteCustomFieldDefinitionContext(ctx context.Context, idOrName string) error {
+	url, err := url.JoinPath(hive.Url, "/api/v1/customField/", idOrName)
+	if err != nil {
+		return err
+	}
+
+	_, err = hive.webRequest(ctx, url, DELETE, nil)
+	return err
+}
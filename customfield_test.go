@@ -0,0 +1,186 @@
+package thehive5_test
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	thehive5 "github.com/b401/goHive5"
+	"github.com/b401/goHive5/thehive5test"
+)
+
+// patches returns the bodies of the PATCH requests received by srv
+func patches(srv *thehive5test.Server) []map[string]interface{} {
+	var bodies []map[string]interface{}
+	for _, req := range srv.Requests() {
+		if req.Method == http.MethodPatch {
+			bodies = append(bodies, req.Body)
+		}
+	}
+	return bodies
+}
+
+func customFieldsByName(fields []thehive5.CustomField) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, field := range fields {
+		values[field.Name] = field.Value
+	}
+	return values
+}
+
+func TestSetCaseCustomFieldSendsOnlyTheField(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	hive := srv.Hive()
+	other := srv.Hive()
+
+	fields := []thehive5.CustomField{{Name: "owner", Value: "soc"}, {Name: "score", Value: 3}}
+	created, err := hive.CreateCase(&thehive5.HiveCase{Title: "case", Description: "case", CustomFields: &fields})
+	if err != nil {
+		t.Fatalf("CreateCase: %v", err)
+	}
+
+	// another integration changes a field between reading and writing the custom fields
+	var changed atomic.Bool
+	srv.Intercept = func(req thehive5test.Request) error {
+		if req.Method == http.MethodPatch && changed.CompareAndSwap(false, true) {
+			if err := other.SetCaseCustomField(created.Number, "owner", "cert"); err != nil {
+				t.Errorf("concurrent SetCaseCustomField: %v", err)
+			}
+		}
+		return nil
+	}
+	srv.ClearRequests()
+
+	detected := time.UnixMilli(1700000000000)
+	if err := hive.SetCaseCustomField(created.Number, "Detected", detected); err != nil {
+		t.Fatalf("SetCaseCustomField: %v", err)
+	}
+
+	want := []map[string]interface{}{
+		{"customFields.detected": float64(1700000000000)},
+		{"customFields.owner": "cert"},
+	}
+	if got := patches(srv); !reflect.DeepEqual(got, want) {
+		t.Fatalf("patches = %v, want %v", got, want)
+	}
+
+	got, err := hive.GetCaseCustomFields(created.Number)
+	if err != nil {
+		t.Fatalf("GetCaseCustomFields: %v", err)
+	}
+	values := customFieldsByName(got)
+	if values["owner"] != "cert" || values["score"] != float64(3) || values["detected"] != float64(1700000000000) {
+		t.Fatalf("custom fields = %v", values)
+	}
+
+	// unchanged values aren't sent
+	srv.ClearRequests()
+	if err := hive.SetCaseCustomField(created.Number, "SCORE", 3); err != nil {
+		t.Fatalf("SetCaseCustomField: %v", err)
+	}
+	if got := patches(srv); len(got) != 0 {
+		t.Fatalf("unchanged value sent %v", got)
+	}
+}
+
+func TestSetCustomFieldChecksTheType(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	hive := srv.Hive()
+
+	fields := []thehive5.CustomField{{Name: "score", Value: 3}, {Name: "link", Value: "https://thehive.local"}}
+	created, err := hive.CreateCase(&thehive5.HiveCase{Title: "case", Description: "case", CustomFields: &fields})
+	if err != nil {
+		t.Fatalf("CreateCase: %v", err)
+	}
+
+	for _, value := range []interface{}{"5", 2.5, true} {
+		err := hive.SetCaseCustomField(created.Number, "score", value)
+		if err == nil || !strings.Contains(err.Error(), "not a valid integer") {
+			t.Fatalf("SetCaseCustomField(score, %#v) = %v, want a type error", value, err)
+		}
+	}
+	if err := hive.SetCaseCustomField(created.Number, "link", "not a url"); err == nil {
+		t.Fatal("SetCaseCustomField accepted a string for an url field")
+	}
+	if got := patches(srv); len(got) != 0 {
+		t.Fatalf("invalid values were sent: %v", got)
+	}
+
+	if err := hive.SetCaseCustomField(created.Number, "score", 5); err != nil {
+		t.Fatalf("SetCaseCustomField(score, 5): %v", err)
+	}
+	if err := hive.SetCaseCustomField(created.Number, "unknown", []int{1}); err == nil {
+		t.Fatal("SetCaseCustomField accepted a value without custom field type")
+	}
+}
+
+func TestAlertCustomFields(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	hive := srv.Hive()
+
+	fields := []thehive5.CustomField{{Name: "owner", Value: "soc"}}
+	alert, err := hive.CreateAlert(&thehive5.HiveAlert{Type: "external", Source: "test", SourceRef: "1", Title: "alert", Description: "alert", CustomFields: &fields})
+	if err != nil {
+		t.Fatalf("CreateAlert: %v", err)
+	}
+
+	if err := hive.SetAlertCustomField(alert.Id, "score", 80); err != nil {
+		t.Fatalf("SetAlertCustomField: %v", err)
+	}
+	want := []map[string]interface{}{{"customFields.score": float64(80)}}
+	if got := patches(srv); !reflect.DeepEqual(got, want) {
+		t.Fatalf("patches = %v, want %v", got, want)
+	}
+
+	if err := hive.RemoveAlertCustomField(alert.Id, "owner"); err != nil {
+		t.Fatalf("RemoveAlertCustomField: %v", err)
+	}
+	if err := hive.RemoveAlertCustomField(alert.Id, "score"); err != nil {
+		t.Fatalf("RemoveAlertCustomField: %v", err)
+	}
+
+	got, err := hive.GetAlert(alert.Id)
+	if err != nil {
+		t.Fatalf("GetAlert: %v", err)
+	}
+	if len(got.CustomFields) != 0 || got.AlertType != "external" {
+		t.Fatalf("alert after removal: type %s custom fields %v", got.AlertType, got.CustomFields)
+	}
+}
+
+func TestCustomFieldDefinitionOptions(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	hive := srv.Hive()
+
+	created, err := hive.CreateCustomFieldDefinition(thehive5.CustomFieldDefinition{Name: "Priority", Type: "integer", Options: []interface{}{1, 2, 3}})
+	if err != nil {
+		t.Fatalf("CreateCustomFieldDefinition: %v", err)
+	}
+	if created.Name != "priority" || created.FieldType != "integer" || len(created.Options) != 3 || created.Options[0] != float64(1) || created.CreatedAt.IsZero() {
+		t.Fatalf("created definition %+v", created)
+	}
+
+	meta, err := hive.LoadMetadata()
+	if err != nil {
+		t.Fatalf("LoadMetadata: %v", err)
+	}
+	if len(meta.CustomFields) != 1 || len(meta.CustomFields[0].Options) != 3 {
+		t.Fatalf("listed definitions %+v", meta.CustomFields)
+	}
+
+	valid := []thehive5.CustomField{{Name: "priority", Value: 2}}
+	if err := (&thehive5.HiveCase{Title: "case", Description: "case", CustomFields: &valid}).ValidateWith(meta); err != nil {
+		t.Fatalf("ValidateWith(priority 2) = %v", err)
+	}
+	invalid := []thehive5.CustomField{{Name: "priority", Value: 4}}
+	if err := (&thehive5.HiveCase{Title: "case", Description: "case", CustomFields: &invalid}).ValidateWith(meta); err == nil || !strings.Contains(err.Error(), "not one of the options") {
+		t.Fatalf("ValidateWith(priority 4) = %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+/*
+thehive5 implements functionality to interact with the most recent version of thehive.
+https://www.strangebee.com/thehive/
+*/
+package thehive5
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Sentinel errors that can be matched with errors.Is against errors returned by the api calls
+var (
+	ErrNotFound    = errors.New("thehive5: not found")
+	ErrAuthFailure = errors.New("thehive5: authentication failure")
+	ErrConflict    = errors.New("thehive5: conflict")
+	ErrBadRequest  = errors.New("thehive5: bad request")
+)
+
+// An APIError is returned for every non 2xx response of thehive5
+// It keeps the http status, the endpoint and the raw body of the response
+type APIError struct {
+	StatusCode int
+	Method     string
+	Path       string
+	Body       []byte
+	// RetryAfter contains the delay requested by the Retry-After header. Zero if it wasn't set
+	RetryAfter time.Duration
+	// Response contains the parsed error of thehive5. nil if the body wasn't json
+	Response *ApiErrorResponse
+}
+
+// newAPIError builds an APIError from a failed http response
+func newAPIError(req *http.Request, resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		Body:       body,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	var errorResp ApiErrorResponse
+	if err := json.Unmarshal(body, &errorResp); err == nil && (len(errorResp.Type) != 0 || len(errorResp.Message) != 0) {
+		apiErr.Response = &errorResp
+	}
+
+	return apiErr
+}
+
+// Type returns the error type thehive5 responded with (e.g. NotFoundError)
+func (e *APIError) Type() string {
+	if e.Response == nil {
+		return ""
+	}
+	return e.Response.Type
+}
+
+// Error() reciever implementation to display API errors
+func (e *APIError) Error() string {
+	if e.Response != nil {
+		return fmt.Sprintf("API error: %s %s returned HTTP %d: %s: %s", e.Method, e.Path, e.StatusCode, e.Response.Type, e.Response.Message)
+	}
+	return fmt.Sprintf("API error: %s %s returned HTTP %d: %s", e.Method, e.Path, e.StatusCode, string(e.Body))
+}
+
+// Unwrap returns the parsed ApiErrorResponse if available
+func (e *APIError) Unwrap() error {
+	if e.Response == nil {
+		return nil
+	}
+	return *e.Response
+}
+
+// Is allows the usage of errors.Is with the sentinel errors of this package
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound || e.Type() == "NotFoundError"
+	case ErrAuthFailure:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden ||
+			e.Type() == "AuthenticationError" || e.Type() == "AuthorizationError"
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict || e.Type() == "ConflictError"
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest || e.Type() == "BadRequestError"
+	}
+	return false
+}
+
+// Retryable reports if the request failed because of a transient error and can be sent again
+func (e *APIError) Retryable() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// IsNotFound reports if err was caused by a missing object on thehive5
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsAuthFailure reports if err was caused by invalid credentials or missing permissions
+func IsAuthFailure(err error) bool {
+	return errors.Is(err, ErrAuthFailure)
+}
+
+// IsConflict reports if err was caused by an object that already exists
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+// IsBadRequest reports if err was caused by an invalid request
+func IsBadRequest(err error) bool {
+	return errors.Is(err, ErrBadRequest)
+}
+
+// IsRetryable reports if err is an APIError caused by a transient server side issue
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package thehive5
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAPIErrorFromResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/case/1":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"type":"NotFoundError","message":"Case 1 not found"}`))
+		case "/api/v1/alert":
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("<html>maintenance</html>"))
+		}
+	}))
+	defer srv.Close()
+
+	hive, err := NewClient(srv.URL, WithAPIKey("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = hive.webRequest(context.Background(), srv.URL+"/api/v1/case/1", GET, nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error %v is no APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound || apiErr.Method != "GET" || apiErr.Path != "/api/v1/case/1" || apiErr.Type() != "NotFoundError" {
+		t.Fatalf("APIError = %+v", apiErr)
+	}
+	if want := "API error: GET /api/v1/case/1 returned HTTP 404: NotFoundError: Case 1 not found"; err.Error() != want {
+		t.Fatalf("Error() = %q, want %q", err.Error(), want)
+	}
+	var response ApiErrorResponse
+	if !errors.As(err, &response) || response.Message != "Case 1 not found" {
+		t.Fatalf("errors.As(ApiErrorResponse) = %+v", response)
+	}
+
+	_, err = hive.webRequest(context.Background(), srv.URL+"/api/v1/alert", POST, []byte(`{}`))
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error %v is no APIError", err)
+	}
+	if apiErr.Response != nil || apiErr.Type() != "" || apiErr.RetryAfter != 7*time.Second || string(apiErr.Body) != "<html>maintenance</html>" {
+		t.Fatalf("APIError without json body = %+v", apiErr)
+	}
+	if want := "API error: POST /api/v1/alert returned HTTP 503: <html>maintenance</html>"; err.Error() != want {
+		t.Fatalf("Error() = %q, want %q", err.Error(), want)
+	}
+	if errors.Unwrap(apiErr) != nil {
+		t.Fatal("APIError without json body unwraps to an error")
+	}
+}
+
+func TestAPIErrorIs(t *testing.T) {
+	withType := func(status int, errType string) *APIError {
+		return &APIError{StatusCode: status, Response: &ApiErrorResponse{Type: errType, Message: "message"}}
+	}
+
+	tests := []struct {
+		name      string
+		err       *APIError
+		notFound  bool
+		auth      bool
+		conflict  bool
+		bad       bool
+		retryable bool
+	}{
+		{name: "404", err: &APIError{StatusCode: http.StatusNotFound}, notFound: true},
+		{name: "NotFoundError", err: withType(http.StatusBadRequest, "NotFoundError"), notFound: true, bad: true},
+		{name: "401", err: &APIError{StatusCode: http.StatusUnauthorized}, auth: true},
+		{name: "403", err: &APIError{StatusCode: http.StatusForbidden}, auth: true},
+		{name: "AuthenticationError", err: withType(http.StatusInternalServerError, "AuthenticationError"), auth: true},
+		{name: "AuthorizationError", err: withType(http.StatusInternalServerError, "AuthorizationError"), auth: true},
+		{name: "409", err: &APIError{StatusCode: http.StatusConflict}, conflict: true},
+		{name: "ConflictError", err: withType(http.StatusBadRequest, "ConflictError"), conflict: true, bad: true},
+		{name: "duplicate alert", err: &APIError{StatusCode: http.StatusBadRequest, Response: &ApiErrorResponse{Type: "CreateError", Message: "Alert external/test/1 already exists"}}, bad: true},
+		{name: "400", err: &APIError{StatusCode: http.StatusBadRequest}, bad: true},
+		{name: "BadRequestError", err: withType(http.StatusInternalServerError, "BadRequestError"), bad: true},
+		{name: "429", err: &APIError{StatusCode: http.StatusTooManyRequests}, retryable: true},
+		{name: "500", err: &APIError{StatusCode: http.StatusInternalServerError}},
+		{name: "502", err: &APIError{StatusCode: http.StatusBadGateway}, retryable: true},
+		{name: "503", err: &APIError{StatusCode: http.StatusServiceUnavailable}, retryable: true},
+		{name: "504", err: &APIError{StatusCode: http.StatusGatewayTimeout}, retryable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fmt.Errorf("request failed: %w", tt.err)
+			checks := []struct {
+				name string
+				got  bool
+				want bool
+			}{
+				{"IsNotFound", IsNotFound(err), tt.notFound},
+				{"IsAuthFailure", IsAuthFailure(err), tt.auth},
+				{"IsConflict", IsConflict(err), tt.conflict},
+				{"IsBadRequest", IsBadRequest(err), tt.bad},
+				{"IsRetryable", IsRetryable(err), tt.retryable},
+				{"Retryable", tt.err.Retryable(), tt.retryable},
+			}
+			for _, check := range checks {
+				if check.got != check.want {
+					t.Errorf("%s = %v, want %v", check.name, check.got, check.want)
+				}
+			}
+		})
+	}
+}
+
+func TestIsWithoutAPIError(t *testing.T) {
+	err := errors.New("connection refused")
+	if IsNotFound(err) || IsAuthFailure(err) || IsConflict(err) || IsBadRequest(err) || IsRetryable(err) || IsRetryable(nil) {
+		t.Fatal("an error without APIError matched")
+	}
+	if (&APIError{StatusCode: http.StatusNotFound}).Is(errors.New("thehive5: not found")) {
+		t.Fatal("Is matched an error which isn't a sentinel of the package")
+	}
+}
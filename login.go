@@ -6,8 +6,13 @@ package thehive5
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"net/http"
+	"net/url"
+	"os"
 	"strings"
+	"time"
 )
 
 // A Hivedata stores the apikey, url and http client for subsequent API calls
@@ -15,6 +20,20 @@ type Hivedata struct {
 	Url    string
 	Apikey string
 	Client HttpClient
+	// Retry defines how failed requests are retried. nil disables retries
+	Retry *RetryPolicy
+	// Limiter throttles the requests of the client. nil disables throttling
+	Limiter *Limiter
+	// UserAgent is sent with every request if set
+	UserAgent string
+	// Headers contains additional headers sent with every request
+	Headers http.Header
+	// Organisation is sent as X-Organisation header to act in a specific organisation
+	// Leave empty to use the default organisation of the user
+	// It replaces an X-Organisation header in Headers
+	Organisation string
+	// Auth authenticates the requests. If nil the Apikey is used as Bearer token
+	Auth Authenticator
 }
 
 // a HttpClient interface gets used for testing
@@ -26,14 +45,233 @@ type HttpClient interface {
 // Defines API login principles that can be reused in requests
 // Returns a Hivedata struct
 func CreateLogin(inurl string, apikey string, verify bool) Hivedata {
-	return Hivedata{
-		Url:    strings.TrimRight(inurl, "/"),
-		Apikey: apikey,
-		Client: &http.Client{
-			Transport: &http.Transport{
-				MaxIdleConnsPerHost: 20,
-				TLSClientConfig:     &tls.Config{InsecureSkipVerify: !verify},
-			},
+	// these options can't fail
+	hive, _ := NewClient(inurl, WithAPIKey(apikey), WithInsecureSkipVerify(!verify))
+	return *hive
+}
+
+// WithOrganisation returns a copy of the client which acts in the given organisation
+// The copy shares the http client, retry policy and limiter with the original client
+func (hive *Hivedata) WithOrganisation(organisation string) *Hivedata {
+	derived := *hive
+	derived.Headers = hive.Headers.Clone()
+	derived.Organisation = organisation
+	return &derived
+}
+
+// An Option configures the client created by NewClient
+type Option func(*clientConfig) error
+
+// clientConfig collects the settings of all options before the client gets built
+type clientConfig struct {
+	hive                *Hivedata
+	tlsConfig           *tls.Config
+	proxy               func(*http.Request) (*url.URL, error)
+	timeout             time.Duration
+	maxIdleConnsPerHost int
+	httpClient          HttpClient
+}
+
+// NewClient creates a new client for the thehive5 instance at inurl
+// Without options it behaves like CreateLogin with certificate verification and no apikey
+func NewClient(inurl string, opts ...Option) (*Hivedata, error) {
+	config := &clientConfig{
+		hive: &Hivedata{
+			Url:     strings.TrimRight(inurl, "/"),
+			Headers: http.Header{},
+		},
+		tlsConfig:           &tls.Config{},
+		maxIdleConnsPerHost: 20,
+	}
+
+	for _, opt := range opts {
+		if err := opt(config); err != nil {
+			return nil, err
+		}
+	}
+
+	hive := config.hive
+	if config.httpClient != nil {
+		hive.Client = config.httpClient
+		return hive, nil
+	}
+
+	hive.Client = &http.Client{
+		Timeout: config.timeout,
+		Transport: &http.Transport{
+			Proxy:               config.proxy,
+			MaxIdleConnsPerHost: config.maxIdleConnsPerHost,
+			TLSClientConfig:     config.tlsConfig,
 		},
 	}
+
+	return hive, nil
+}
+
+// WithAPIKey sets the apikey used as Bearer token
+func WithAPIKey(apikey string) Option {
+	return func(c *clientConfig) error {
+		c.hive.Apikey = apikey
+		return nil
+	}
+}
+
+// WithAuthenticator sets a custom Authenticator
+func WithAuthenticator(auth Authenticator) Option {
+	return func(c *clientConfig) error {
+		c.hive.Auth = auth
+		return nil
+	}
+}
+
+// WithBasicAuth authenticates every request with username and password
+func WithBasicAuth(username string, password string) Option {
+	return WithAuthenticator(&BasicAuth{Username: username, Password: password})
+}
+
+// WithSessionAuth logs in with username and password and uses the session cookie
+func WithSessionAuth(username string, password string) Option {
+	return WithAuthenticator(NewSessionAuth(username, password))
+}
+
+// WithInsecureSkipVerify disables the verification of the server certificate
+func WithInsecureSkipVerify(skip bool) Option {
+	return func(c *clientConfig) error {
+		c.tlsConfig.InsecureSkipVerify = skip
+		return nil
+	}
+}
+
+// WithCABundle adds PEM encoded CA certificates to the trusted roots
+// The system roots are still trusted
+func WithCABundle(pemCerts []byte) Option {
+	return func(c *clientConfig) error {
+		if c.tlsConfig.RootCAs == nil {
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			c.tlsConfig.RootCAs = pool
+		}
+
+		if !c.tlsConfig.RootCAs.AppendCertsFromPEM(pemCerts) {
+			return fmt.Errorf("no valid certificates found in CA bundle")
+		}
+		return nil
+	}
+}
+
+// WithCAFile adds the PEM encoded CA certificates of a file to the trusted roots
+func WithCAFile(path string) Option {
+	return func(c *clientConfig) error {
+		pemCerts, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return WithCABundle(pemCerts)(c)
+	}
+}
+
+// WithClientCertificate sets the certificate used for mutual TLS
+func WithClientCertificate(cert tls.Certificate) Option {
+	return func(c *clientConfig) error {
+		c.tlsConfig.Certificates = append(c.tlsConfig.Certificates, cert)
+		return nil
+	}
+}
+
+// WithClientCertificateFiles loads a PEM encoded certificate and key used for mutual TLS
+func WithClientCertificateFiles(certFile string, keyFile string) Option {
+	return func(c *clientConfig) error {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		return WithClientCertificate(cert)(c)
+	}
+}
+
+// WithProxy sends all requests through the given http proxy
+func WithProxy(proxyUrl string) Option {
+	return func(c *clientConfig) error {
+		parsed, err := url.Parse(proxyUrl)
+		if err != nil {
+			return err
+		}
+		c.proxy = http.ProxyURL(parsed)
+		return nil
+	}
+}
+
+// WithProxyFromEnvironment uses the proxy defined in HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+func WithProxyFromEnvironment() Option {
+	return func(c *clientConfig) error {
+		c.proxy = http.ProxyFromEnvironment
+		return nil
+	}
+}
+
+// WithTimeout limits the time a single request may take including reading the body
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *clientConfig) error {
+		c.timeout = timeout
+		return nil
+	}
+}
+
+// WithMaxIdleConnsPerHost sets the number of kept-alive connections. Defaults to 20
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(c *clientConfig) error {
+		c.maxIdleConnsPerHost = n
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(c *clientConfig) error {
+		c.hive.UserAgent = userAgent
+		return nil
+	}
+}
+
+// WithHeader adds a header that is sent with every request
+func WithHeader(key string, value string) Option {
+	return func(c *clientConfig) error {
+		c.hive.Headers.Add(key, value)
+		return nil
+	}
+}
+
+// WithDefaultOrganisation sets the organisation used by the client
+func WithDefaultOrganisation(organisation string) Option {
+	return func(c *clientConfig) error {
+		c.hive.Organisation = organisation
+		return nil
+	}
+}
+
+// WithHTTPClient injects the client used to send the requests
+// The TLS, proxy and timeout options are ignored if a client is injected
+func WithHTTPClient(client HttpClient) Option {
+	return func(c *clientConfig) error {
+		c.httpClient = client
+		return nil
+	}
+}
+
+// WithRetryPolicy sets the policy used to retry failed requests
+func WithRetryPolicy(policy *RetryPolicy) Option {
+	return func(c *clientConfig) error {
+		c.hive.Retry = policy
+		return nil
+	}
+}
+
+// WithLimiter sets the limiter used to throttle the requests
+func WithLimiter(limiter *Limiter) Option {
+	return func(c *clientConfig) error {
+		c.hive.Limiter = limiter
+		return nil
+	}
 }
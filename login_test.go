@@ -0,0 +1,334 @@
+package thehive5
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// headerServer answers every request with {} and keeps the headers of the last one
+func headerServer(t *testing.T) (*httptest.Server, *http.Header) {
+	t.Helper()
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &header
+}
+
+// clientCertificate creates a self signed certificate for client authentication
+func clientCertificate(t *testing.T) (tls.Certificate, []byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gohive5 test client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, certPEM, keyPEM
+}
+
+func TestHeaderOptions(t *testing.T) {
+	srv, header := headerServer(t)
+	hive, err := NewClient(srv.URL+"/", WithAPIKey("secret"), WithUserAgent("soar/1.0"),
+		WithHeader("X-Request-Source", "soar"), WithHeader("X-Request-Source", "test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hive.Url != srv.URL {
+		t.Fatalf("Url = %q, the trailing slash wasn't removed", hive.Url)
+	}
+
+	if _, err := hive.webRequest(context.Background(), srv.URL+"/api/v1/case/1", GET, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Authorization = %q", got)
+	}
+	if got := header.Get("User-Agent"); got != "soar/1.0" {
+		t.Errorf("User-Agent = %q", got)
+	}
+	if got := header.Values("X-Request-Source"); len(got) != 2 || got[0] != "soar" || got[1] != "test" {
+		t.Errorf("X-Request-Source = %q", got)
+	}
+	if got := header.Get("Accept"); got != "application/json" {
+		t.Errorf("Accept = %q", got)
+	}
+}
+
+func TestCABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	untrusted, err := NewClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var unknownAuthority x509.UnknownAuthorityError
+	if _, err := untrusted.webRequest(context.Background(), srv.URL, GET, nil); !errors.As(err, &unknownAuthority) {
+		t.Fatalf("request without the CA = %v, want an unknown authority", err)
+	}
+
+	for name, option := range map[string]Option{"WithCABundle": WithCABundle(caPEM), "WithCAFile": WithCAFile(caFile), "WithInsecureSkipVerify": WithInsecureSkipVerify(true)} {
+		hive, err := NewClient(srv.URL, option)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, err := hive.webRequest(context.Background(), srv.URL, GET, nil); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	if _, err := NewClient(srv.URL, WithCABundle([]byte("no certificate"))); err == nil {
+		t.Fatal("WithCABundle accepted a bundle without certificates")
+	}
+	if _, err := NewClient(srv.URL, WithCAFile(filepath.Join(t.TempDir(), "missing.pem"))); err == nil {
+		t.Fatal("WithCAFile accepted a missing file")
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	cert, certPEM, keyPEM := clientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(certPEM)
+
+	var peer string
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer = r.TLS.PeerCertificates[0].Subject.CommonName
+		w.Write([]byte(`{}`))
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	defer srv.Close()
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	os.WriteFile(certFile, certPEM, 0o600)
+	os.WriteFile(keyFile, keyPEM, 0o600)
+
+	anonymous, err := NewClient(srv.URL, WithInsecureSkipVerify(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := anonymous.webRequest(context.Background(), srv.URL, GET, nil); err == nil {
+		t.Fatal("request without client certificate succeeded")
+	}
+
+	for name, option := range map[string]Option{"WithClientCertificate": WithClientCertificate(cert), "WithClientCertificateFiles": WithClientCertificateFiles(certFile, keyFile)} {
+		peer = ""
+		hive, err := NewClient(srv.URL, WithInsecureSkipVerify(true), option)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, err := hive.webRequest(context.Background(), srv.URL, GET, nil); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if peer != "gohive5 test client" {
+			t.Fatalf("%s: server saw client certificate %q", name, peer)
+		}
+	}
+
+	if _, err := NewClient(srv.URL, WithClientCertificateFiles(keyFile, certFile)); err == nil {
+		t.Fatal("WithClientCertificateFiles accepted swapped files")
+	}
+}
+
+func TestProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+
+	hive, err := NewClient("http://thehive.invalid", WithProxy(proxy.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hive.webRequest(context.Background(), hive.Url+"/api/v1/case/1", GET, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(proxied) != 1 || proxied[0] != "http://thehive.invalid/api/v1/case/1" {
+		t.Fatalf("proxy received %v", proxied)
+	}
+
+	if _, err := NewClient("http://thehive.invalid", WithProxy("http://[::1")); err == nil {
+		t.Fatal("WithProxy accepted an invalid url")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	hive, err := NewClient(srv.URL, WithTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	_, err = hive.webRequest(context.Background(), srv.URL, GET, nil)
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("request = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("timeout after %v", elapsed)
+	}
+}
+
+func TestTransportOptions(t *testing.T) {
+	hive, err := NewClient("https://thehive.local", WithMaxIdleConnsPerHost(5), WithInsecureSkipVerify(true), WithTimeout(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := hive.Client.(*http.Client)
+	transport := client.Transport.(*http.Transport)
+	if transport.MaxIdleConnsPerHost != 5 || !transport.TLSClientConfig.InsecureSkipVerify || client.Timeout != time.Minute || transport.Proxy != nil {
+		t.Fatalf("transport = %+v, timeout %v", transport, client.Timeout)
+	}
+
+	verified := CreateLogin("https://thehive.local", "key", true)
+	if verified.Apikey != "key" || verified.Client.(*http.Client).Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify {
+		t.Fatal("CreateLogin with verify skips the certificate verification")
+	}
+	unverified := CreateLogin("https://thehive.local", "key", false)
+	if !unverified.Client.(*http.Client).Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify {
+		t.Fatal("CreateLogin without verify checks the certificate")
+	}
+}
+
+// WithHTTPClient replaces the client built from the TLS, proxy and timeout options, they have no effect
+func TestHTTPClientIgnoresTransportOptions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	injected := srv.Client()
+	hive, err := NewClient(srv.URL,
+		WithProxy("http://proxy.invalid:3128"),
+		WithTimeout(time.Millisecond),
+		WithCABundle(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: mustCertificate(t)})),
+		WithHTTPClient(injected),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hive.Client != injected {
+		t.Fatal("the injected client isn't used")
+	}
+	// neither the proxy nor the timeout of 1ms are applied
+	if _, err := hive.webRequest(context.Background(), srv.URL, GET, nil); err != nil {
+		t.Fatalf("request with the injected client: %v", err)
+	}
+}
+
+// mustCertificate returns a DER encoded certificate
+func mustCertificate(t *testing.T) []byte {
+	cert, _, _ := clientCertificate(t)
+	return cert.Certificate[0]
+}
+
+func TestOptionErrorsAreReturned(t *testing.T) {
+	failing := func(c *clientConfig) error { return errors.New("option failed") }
+	if _, err := NewClient("https://thehive.local", failing); err == nil || !strings.Contains(err.Error(), "option failed") {
+		t.Fatalf("NewClient = %v", err)
+	}
+}
+
+func TestWithOrganisation(t *testing.T) {
+	srv, header := headerServer(t)
+	hive, err := NewClient(srv.URL, WithDefaultOrganisation("admin"), WithHeader("X-Organisation", "from-header"), WithHeader("X-Request-Source", "soar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenant := hive.WithOrganisation("tenant-a")
+	tenant.Headers.Set("X-Request-Source", "tenant")
+
+	if _, err := tenant.webRequest(context.Background(), srv.URL, GET, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Values("X-Organisation"); len(got) != 1 || got[0] != "tenant-a" {
+		t.Fatalf("derived client sent X-Organisation %q", got)
+	}
+	if got := header.Get("X-Request-Source"); got != "tenant" {
+		t.Fatalf("derived client sent X-Request-Source %q", got)
+	}
+
+	// the original client keeps its organisation and headers
+	if _, err := hive.webRequest(context.Background(), srv.URL, GET, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Values("X-Organisation"); len(got) != 1 || got[0] != "admin" {
+		t.Fatalf("original client sent X-Organisation %q", got)
+	}
+	if got := header.Get("X-Request-Source"); got != "soar" {
+		t.Fatalf("original client sent X-Request-Source %q", got)
+	}
+	if tenant.Client != hive.Client || tenant.Limiter != hive.Limiter {
+		t.Fatal("the derived client doesn't share the http client and limiter")
+	}
+
+	// without an organisation the header of WithHeader is sent as is
+	plain, err := NewClient(srv.URL, WithHeader("X-Organisation", "from-header"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := plain.webRequest(context.Background(), srv.URL, GET, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Values("X-Organisation"); len(got) != 1 || got[0] != "from-header" {
+		t.Fatalf("client without organisation sent X-Organisation %q", got)
+	}
+}
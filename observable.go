@@ -5,6 +5,7 @@ https://www.strangebee.com/thehive/
 package thehive5
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"os"
@@ -209,12 +210,12 @@ func (or *ObservableTypeResponse) UnmarshalJSON(data []byte) error {
 }
 
 // executeObservableTypeSearchQuery is a helper function to do query related searches
-func (hive *Hivedata) executeObservableTypeSearchQuery(query []byte) ([]ObservableTypeResponse, error) {
+func (hive *Hivedata) executeObservableTypeSearchQuery(ctx context.Context, query []byte) ([]ObservableTypeResponse, error) {
 	url, err := url.JoinPath(hive.Url, "/api/v1/query")
 	if err != nil {
 		return nil, err
 	}
-	ret, err := hive.webRequest(url, POST, query)
+	ret, err := hive.webRequest(ctx, url, POST, query)
 
 	if err != nil {
 		return nil, err
@@ -226,12 +227,12 @@ func (hive *Hivedata) executeObservableTypeSearchQuery(query []byte) ([]Observab
 }
 
 // executeObservableSearchQuery is a helper function to do query related searches
-func (hive *Hivedata) executeObservableSearchQuery(query []byte) ([]ObservableResponse, error) {
+func (hive *Hivedata) executeObservableSearchQuery(ctx context.Context, query []byte) ([]ObservableResponse, error) {
 	url, err := url.JoinPath(hive.Url, "/api/v1/query")
 	if err != nil {
 		return nil, err
 	}
-	ret, err := hive.webRequest(url, POST, query)
+	ret, err := hive.webRequest(ctx, url, POST, query)
 
 	if err != nil {
 		return nil, err
@@ -244,6 +245,11 @@ func (hive *Hivedata) executeObservableSearchQuery(query []byte) ([]ObservableRe
 
 // GetObservableTypes returns all types an observable can be
 func (hive *Hivedata) GetObservableTypes() ([]ObservableTypeResponse, error) {
+	return hive.GetObservableTypesContext(context.Background())
+}
+
+// GetObservableTypesContext is like GetObservableTypes but uses ctx for the request
+func (hive *Hivedata) GetObservableTypesContext(ctx context.Context) ([]ObservableTypeResponse, error) {
 	query, err := hive.createSearchQuery(
 		SearchQuery{Name: "listObservableType"},
 		SearchQuery{Name: "sort", Sort: &[1]map[string]string{{"_updatedAt": "desc"}}},
@@ -252,11 +258,16 @@ func (hive *Hivedata) GetObservableTypes() ([]ObservableTypeResponse, error) {
 		return nil, err
 	}
 
-	return hive.executeObservableTypeSearchQuery(query)
+	return hive.executeObservableTypeSearchQuery(ctx, query)
 }
 
 // AddCaseObservable adds observables to an existing case.
 func (hive *Hivedata) AddCaseObservable(incidentNumber int, observable *Observable) error {
+	return hive.AddCaseObservableContext(context.Background(), incidentNumber, observable)
+}
+
+// AddCaseObservableContext is like AddCaseObservable but uses ctx for the request
+func (hive *Hivedata) AddCaseObservableContext(ctx context.Context, incidentNumber int, observable *Observable) error {
 	url, err := url.JoinPath(hive.Url, "/api/v1/case/", strconv.Itoa(incidentNumber), "/observable")
 	if err != nil {
 		return err
@@ -267,12 +278,17 @@ func (hive *Hivedata) AddCaseObservable(incidentNumber int, observable *Observab
 		return err
 	}
 
-	_, err = hive.webRequest(url, POST, jsondata)
+	_, err = hive.webRequest(ctx, url, POST, jsondata)
 	return err
 }
 
 // AddCaseObservableFile adds a file as an observable to a case.
 func (hive *Hivedata) AddCaseObservableFile(incidentNumber int, observable *Observable, file *os.File) error {
+	return hive.AddCaseObservableFileContext(context.Background(), incidentNumber, observable, file)
+}
+
+// AddCaseObservableFileContext is like AddCaseObservableFile but uses ctx for the request
+func (hive *Hivedata) AddCaseObservableFileContext(ctx context.Context, incidentNumber int, observable *Observable, file *os.File) error {
 	url, err := url.JoinPath(hive.Url, "/api/v1/case/", strconv.Itoa(incidentNumber), "/observable")
 	if err != nil {
 		return err
@@ -283,13 +299,18 @@ func (hive *Hivedata) AddCaseObservableFile(incidentNumber int, observable *Obse
 		return err
 	}
 
-	_, err = hive.webRequestMultiPart(url, POST, jsondata, file)
+	_, err = hive.webRequestMultiPart(ctx, url, POST, jsondata, file)
 	return err
 }
 
 // GetCaseObservables returns all observables associated with a case
 // It returns an observable slice or an error
 func (hive *Hivedata) GetCaseObservables(caseId int) ([]ObservableResponse, error) {
+	return hive.GetCaseObservablesContext(context.Background(), caseId)
+}
+
+// GetCaseObservablesContext is like GetCaseObservables but uses ctx for the request
+func (hive *Hivedata) GetCaseObservablesContext(ctx context.Context, caseId int) ([]ObservableResponse, error) {
 	caseNumber := strconv.Itoa(caseId)
 	query, err := hive.createSearchQuery(
 		SearchQuery{Name: "getCase", IdOrName: caseNumber},
@@ -299,24 +320,34 @@ func (hive *Hivedata) GetCaseObservables(caseId int) ([]ObservableResponse, erro
 		return nil, err
 	}
 
-	return hive.executeObservableSearchQuery(query)
+	return hive.executeObservableSearchQuery(ctx, query)
 }
 
 // DeleteObservable deletes an observable
 // Only returns data if an error occured
 func (hive *Hivedata) DeleteObservable(observableID string) error {
+	return hive.DeleteObservableContext(context.Background(), observableID)
+}
+
+// DeleteObservableContext is like DeleteObservable but uses ctx for the request
+func (hive *Hivedata) DeleteObservableContext(ctx context.Context, observableID string) error {
 	url, err := url.JoinPath(hive.Url, "/api/v1/observable/", observableID)
 	if err != nil {
 		return err
 	}
 
-	_, err = hive.webRequest(url, DELETE, nil)
+	_, err = hive.webRequest(ctx, url, DELETE, nil)
 	return err
 }
 
 // DeleteObservable deletes an observable specified by the ID got through GetObservables
 // Only returns data if an error occured
 func (hive *Hivedata) UpdateObservable(observableID string, observable *Observable) error {
+	return hive.UpdateObservableContext(context.Background(), observableID, observable)
+}
+
+// UpdateObservableContext is like UpdateObservable but uses ctx for the request
+func (hive *Hivedata) UpdateObservableContext(ctx context.Context, observableID string, observable *Observable) error {
 	url, err := url.JoinPath(hive.Url, "/api/v1/observable/", observableID)
 	if err != nil {
 		return err
@@ -327,19 +358,24 @@ func (hive *Hivedata) UpdateObservable(observableID string, observable *Observab
 		return err
 	}
 
-	_, err = hive.webRequest(url, PATCH, jsonsearch)
+	_, err = hive.webRequest(ctx, url, PATCH, jsonsearch)
 	return err
 }
 
 // Get a single Observable
 // It returns a pointer to an observable object or an error
 func (hive *Hivedata) GetObservable(observableID string) (*ObservableResponse, error) {
+	return hive.GetObservableContext(context.Background(), observableID)
+}
+
+// GetObservableContext is like GetObservable but uses ctx for the request
+func (hive *Hivedata) GetObservableContext(ctx context.Context, observableID string) (*ObservableResponse, error) {
 	url, err := url.JoinPath(hive.Url, "/api/v1/observable/", observableID)
 	if err != nil {
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, GET, nil)
+	ret, err := hive.webRequest(ctx, url, GET, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -352,6 +388,11 @@ func (hive *Hivedata) GetObservable(observableID string) (*ObservableResponse, e
 // GetCaseObservableFiltered returns a single specified observable associated with a case filtered on a field & value
 // It returns an observable slice or an error
 func (hive *Hivedata) GetCaseObservablesFiltered(caseId int, queryfield, queryvalue string) ([]ObservableResponse, error) {
+	return hive.GetCaseObservablesFilteredContext(context.Background(), caseId, queryfield, queryvalue)
+}
+
+// GetCaseObservablesFilteredContext is like GetCaseObservablesFiltered but uses ctx for the request
+func (hive *Hivedata) GetCaseObservablesFilteredContext(ctx context.Context, caseId int, queryfield, queryvalue string) ([]ObservableResponse, error) {
 	caseNumber := strconv.Itoa(caseId)
 	query, err := hive.createSearchQuery(
 		SearchQuery{Name: "getCase", IdOrName: caseNumber},
@@ -361,21 +402,33 @@ func (hive *Hivedata) GetCaseObservablesFiltered(caseId int, queryfield, queryva
 		return nil, err
 	}
 
-	return hive.executeObservableSearchQuery(query)
+	return hive.executeObservableSearchQuery(ctx, query)
 }
 
 // Find an observable globally
 // It returns a pointer to an ObservableResponse slice or an error
 // Be aware that the ObservableResponse will contain a ExtraData field which contains a HiveCaseResponse or HiveAlerResponse object
+// All matching observables are returned, the results are fetched page by page
 func (hive *Hivedata) FindObservable(value string) ([]ObservableResponse, error) {
-	query, err := hive.createSearchQuery(
+	return hive.FindObservableContext(context.Background(), value)
+}
+
+// FindObservableContext is like FindObservable but uses ctx for the request
+func (hive *Hivedata) FindObservableContext(ctx context.Context, value string) ([]ObservableResponse, error) {
+	return NewQueryContext[ObservableResponse](ctx, hive,
 		SearchQuery{Name: "listObservable"},
 		SearchQuery{Name: "filter", And: &[]Filter{{Field: "keyword", Value: &value}}},
 		SearchQuery{Name: "sort", Sort: &[1]map[string]string{{"_createdAt": "desc"}}},
-		SearchQuery{Name: "page", ScopeFrom: 0, ScopeTo: 10, ExtraData: []string{"links"}})
-	if err != nil {
-		return nil, err
-	}
+	).ExtraData("links").All()
+}
+
+// CountObservables returns the number of observables matching the filter stages
+// Without filters all observables are counted
+func (hive *Hivedata) CountObservables(filters ...SearchQuery) (int, error) {
+	return hive.CountObservablesContext(context.Background(), filters...)
+}
 
-	return hive.executeObservableSearchQuery(query)
+// CountObservablesContext is like CountObservables but uses ctx for the request
+func (hive *Hivedata) CountObservablesContext(ctx context.Context, filters ...SearchQuery) (int, error) {
+	return hive.CountContext(ctx, append([]SearchQuery{{Name: "listObservable"}}, filters...))
 }
@@ -0,0 +1,191 @@
+/*
+thehive5 implements functionality to interact with the most recent version of thehive.
+https://www.strangebee.com/thehive/
+*/
+package thehive5
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// DefaultPageSize is the number of results a Query fetches per request
+const DefaultPageSize = 100
+
+// A Query walks through the results of the /api/v1/query endpoint page by page
+// T is the type of the returned objects, e.g. HiveCaseResponse, HiveAlertResponse or ObservableResponse
+//
+// The stages must not contain a page stage, it gets appended for every request.
+// Add a sort stage to get a stable order across pages.
+//
+//	q := thehive5.NewQuery[thehive5.HiveCaseResponse](hive, thehive5.SearchQuery{Name: "listCase"})
+//	for q.Next() {
+//		fmt.Println(q.Item().Title)
+//	}
+//	if err := q.Err(); err != nil {
+//		...
+//	}
+type Query[T any] struct {
+	hive      *Hivedata
+	ctx       context.Context
+	stages    []SearchQuery
+	pageSize  int
+	extraData []string
+
+	from  int
+	page  []T
+	index int
+	done  bool
+	err   error
+}
+
+// NewQuery creates a new paginated query
+func NewQuery[T any](hive *Hivedata, stages ...SearchQuery) *Query[T] {
+	return NewQueryContext[T](context.Background(), hive, stages...)
+}
+
+// NewQueryContext is like NewQuery but uses ctx for all requests
+func NewQueryContext[T any](ctx context.Context, hive *Hivedata, stages ...SearchQuery) *Query[T] {
+	return &Query[T]{
+		hive:     hive,
+		ctx:      ctx,
+		stages:   stages,
+		pageSize: DefaultPageSize,
+		index:    -1,
+	}
+}
+
+// PageSize sets the number of results fetched per request
+func (q *Query[T]) PageSize(size int) *Query[T] {
+	if size > 0 {
+		q.pageSize = size
+	}
+	return q
+}
+
+// ExtraData requests additional data (e.g. "links") on the page stage
+func (q *Query[T]) ExtraData(fields ...string) *Query[T] {
+	q.extraData = fields
+	return q
+}
+
+// Next advances to the next result and fetches a new page if necessary
+// It returns false once all results were read or an error occured
+func (q *Query[T]) Next() bool {
+	if q.err != nil {
+		return false
+	}
+
+	q.index++
+	if q.index < len(q.page) {
+		return true
+	}
+
+	if q.done {
+		return false
+	}
+
+	q.err = q.fetch()
+	if q.err != nil {
+		return false
+	}
+
+	q.index = 0
+	return len(q.page) > 0
+}
+
+// Item returns the current result
+// It returns the zero value if Next wasn't called or returned false
+func (q *Query[T]) Item() T {
+	if q.index < 0 || q.index >= len(q.page) {
+		var zero T
+		return zero
+	}
+	return q.page[q.index]
+}
+
+// Err returns the error which stopped the iteration
+func (q *Query[T]) Err() error {
+	return q.err
+}
+
+// All reads all remaining results
+func (q *Query[T]) All() ([]T, error) {
+	var results []T
+	for q.Next() {
+		results = append(results, q.Item())
+	}
+	return results, q.Err()
+}
+
+// fetch requests the next page. A page shorter than the page size is the last one
+// A page longer than the page size means the page stage was ignored and the paging would never end
+func (q *Query[T]) fetch() error {
+	stages := append([]SearchQuery{}, q.stages...)
+	stages = append(stages, SearchQuery{Name: "page", ScopeFrom: q.from, ScopeTo: q.from + q.pageSize, ExtraData: q.extraData})
+
+	query, err := q.hive.createSearchQuery(stages...)
+	if err != nil {
+		return err
+	}
+
+	url, err := url.JoinPath(q.hive.Url, "/api/v1/query")
+	if err != nil {
+		return err
+	}
+
+	ret, err := q.hive.webRequest(q.ctx, url, POST, query)
+	if err != nil {
+		return err
+	}
+
+	var page []T
+	err = json.Unmarshal(ret, &page)
+	if err != nil {
+		return err
+	}
+
+	if len(page) > q.pageSize {
+		return fmt.Errorf("thehive5 returned %d results for a page of %d, the page stage was ignored", len(page), q.pageSize)
+	}
+
+	q.page = page
+	q.from += q.pageSize
+	if len(page) < q.pageSize {
+		q.done = true
+	}
+
+	return nil
+}
+
+// Count appends the count stage to the query and returns the number of matching objects
+func (hive *Hivedata) Count(searchQuery []SearchQuery) (int, error) {
+	return hive.CountContext(context.Background(), searchQuery)
+}
+
+// CountContext is like Count but uses ctx for the request
+func (hive *Hivedata) CountContext(ctx context.Context, searchQuery []SearchQuery) (int, error) {
+	stages := append([]SearchQuery{}, searchQuery...)
+	stages = append(stages, SearchQuery{Name: "count"})
+
+	query, err := hive.createSearchQuery(stages...)
+	if err != nil {
+		return 0, err
+	}
+
+	url, err := url.JoinPath(hive.Url, "/api/v1/query")
+	if err != nil {
+		return 0, err
+	}
+
+	ret, err := hive.webRequest(ctx, url, POST, query)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = json.Unmarshal(ret, &count)
+	return count, err
+}
@@ -0,0 +1,219 @@
+package thehive5_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	thehive5 "github.com/b401/goHive5"
+	"github.com/b401/goHive5/thehive5test"
+)
+
+func createCases(t *testing.T, hive *thehive5.Hivedata, severities ...string) []*thehive5.HiveCaseResponse {
+	t.Helper()
+	var cases []*thehive5.HiveCaseResponse
+	for i, severity := range severities {
+		created, err := hive.CreateCase(&thehive5.HiveCase{
+			Title:       fmt.Sprintf("case %d", i),
+			Description: "description",
+			Severity:    severity,
+		})
+		if err != nil {
+			t.Fatalf("CreateCase: %v", err)
+		}
+		cases = append(cases, created)
+	}
+	return cases
+}
+
+// filter returns a filter stage for the Count functions
+func filter(expr thehive5.Expr) thehive5.SearchQuery {
+	return thehive5.SearchQuery{Name: "filter", Filter: &expr}
+}
+
+// queries returns the number of requests sent to the query endpoint
+func queries(srv *thehive5test.Server) int {
+	n := 0
+	for _, req := range srv.Requests() {
+		if req.Path == "/api/v1/query" {
+			n++
+		}
+	}
+	return n
+}
+
+func TestQueryPages(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	hive := srv.Hive()
+
+	var severities []string
+	for i := 0; i < 25; i++ {
+		severities = append(severities, []string{"low", "high"}[i%2])
+	}
+	createCases(t, hive, severities...)
+
+	srv.ClearRequests()
+	high, err := thehive5.NewQuery[thehive5.HiveCaseResponse](hive,
+		thehive5.Cases().Where(thehive5.Eq("severity", int(thehive5.SeverityHigh))).SortBy("number", thehive5.Asc).Build()...,
+	).PageSize(5).All()
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if len(high) != 12 {
+		t.Fatalf("got %d high cases, want 12", len(high))
+	}
+	for i, c := range high {
+		if c.Number != 2*i+2 {
+			t.Fatalf("case %d has number %d, want %d", i, c.Number, 2*i+2)
+		}
+	}
+	// two full pages and a partial one
+	if n := queries(srv); n != 3 {
+		t.Fatalf("paging sent %d requests, want 3", n)
+	}
+}
+
+func TestQueryItem(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	hive := srv.Hive()
+	createCases(t, hive, "low")
+
+	q := thehive5.NewQuery[thehive5.HiveCaseResponse](hive, thehive5.Cases().Build()...)
+	if item := q.Item(); item.Number != 0 {
+		t.Fatalf("Item before Next = %+v, want the zero value", item)
+	}
+	if !q.Next() || q.Item().Number != 1 {
+		t.Fatalf("Next = %+v, %v", q.Item(), q.Err())
+	}
+	if q.Next() {
+		t.Fatal("Next after the last result returned true")
+	}
+	if item := q.Item(); item.Number != 0 || q.Err() != nil {
+		t.Fatalf("Item after the last result = %+v, %v", item, q.Err())
+	}
+}
+
+func TestQueryStopsIfThePageIsIgnored(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`[{"number":1},{"number":2},{"number":3}]`))
+	}))
+	defer srv.Close()
+
+	hive, err := thehive5.NewClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := thehive5.NewQuery[thehive5.HiveCaseResponse](hive, thehive5.Cases().Build()...).PageSize(2)
+	cases, err := q.All()
+	if err == nil || !strings.Contains(err.Error(), "page stage was ignored") {
+		t.Fatalf("All = %v, want an error", err)
+	}
+	if len(cases) != 0 || requests != 1 {
+		t.Fatalf("got %d cases after %d requests", len(cases), requests)
+	}
+	if item := q.Item(); item.Number != 0 {
+		t.Fatalf("Item after the error = %+v", item)
+	}
+}
+
+// the finders used to return a single page, they now page through all results
+func TestFindersReturnAllResults(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	hive := srv.Hive()
+
+	count := thehive5.DefaultPageSize + 5
+	var observables []thehive5.Observable
+	for i := 0; i < count; i++ {
+		observables = append(observables, thehive5.Observable{DataType: "domain", Data: fmt.Sprintf("evil-%d.example", i)})
+	}
+	if _, err := hive.CreateAlert(&thehive5.HiveAlert{Type: "external", Source: "test", SourceRef: "1", Title: "alert", Description: "alert", Observables: &observables}); err != nil {
+		t.Fatalf("CreateAlert: %v", err)
+	}
+	for i := 0; i < count; i++ {
+		fields := []thehive5.CustomField{{Name: "owner", Value: "soc"}}
+		created, err := hive.CreateCase(&thehive5.HiveCase{Title: "case", Description: "case", CustomFields: &fields})
+		if err != nil {
+			t.Fatalf("CreateCase: %v", err)
+		}
+		// the timed finders filter on _updatedAt which is only set once an object was updated
+		if err := hive.UpdateCase(created.Number, &thehive5.HiveUpdateCase{Status: "InProgress"}); err != nil {
+			t.Fatalf("UpdateCase: %v", err)
+		}
+	}
+
+	srv.ClearRequests()
+	found, err := hive.FindObservable("evil")
+	if err != nil || len(found) != count {
+		t.Fatalf("FindObservable = %d observables, %v, want %d", len(found), err, count)
+	}
+	if n := queries(srv); n != 2 {
+		t.Fatalf("FindObservable sent %d queries, want 2", n)
+	}
+
+	cases, err := hive.GetCasesTimed(time.Now().Add(-time.Hour))
+	if err != nil || len(cases) != count {
+		t.Fatalf("GetCasesTimed = %d cases, %v, want %d", len(cases), err, count)
+	}
+	cases, err = hive.FindCaseByCustomField("owner", "soc")
+	if err != nil || len(cases) != count {
+		t.Fatalf("FindCaseByCustomField = %d cases, %v, want %d", len(cases), err, count)
+	}
+}
+
+func TestCounts(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	hive := srv.Hive()
+
+	var severities []string
+	for i := 0; i < 25; i++ {
+		severities = append(severities, []string{"low", "high"}[i%2])
+	}
+	createCases(t, hive, severities...)
+
+	total, err := hive.CountCases()
+	if err != nil || total != 25 {
+		t.Fatalf("CountCases = %d, %v, want 25", total, err)
+	}
+	low, err := hive.CountCases(filter(thehive5.Eq("severity", int(thehive5.SeverityLow))))
+	if err != nil || low != 13 {
+		t.Fatalf("CountCases(low) = %d, %v, want 13", low, err)
+	}
+
+	observables := []thehive5.Observable{{DataType: "ip", Data: "10.0.0.1", Ioc: true}, {DataType: "domain", Data: "evil.example"}}
+	for _, sourceRef := range []string{"1", "2", "3"} {
+		if _, err := hive.CreateAlert(&thehive5.HiveAlert{Type: "external", Source: "test", SourceRef: sourceRef, Title: "alert", Description: "alert", Observables: &observables}); err != nil {
+			t.Fatalf("CreateAlert: %v", err)
+		}
+	}
+	alerts, err := hive.CountAlerts(filter(thehive5.Eq("sourceRef", "2")))
+	if err != nil || alerts != 1 {
+		t.Fatalf("CountAlerts(sourceRef 2) = %d, %v, want 1", alerts, err)
+	}
+	iocs, err := hive.CountObservables(filter(thehive5.Eq("ioc", true)))
+	if err != nil || iocs != 3 {
+		t.Fatalf("CountObservables(ioc) = %d, %v, want 3", iocs, err)
+	}
+
+	// the count is a single request without page stage
+	srv.ClearRequests()
+	if _, err := hive.Count(thehive5.Observables().Build()); err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	requests := srv.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("Count sent %d requests", len(requests))
+	}
+	stages := requests[0].Body["query"].([]interface{})
+	if last := stages[len(stages)-1].(map[string]interface{}); last["_name"] != "count" || len(stages) != 2 {
+		t.Fatalf("Count sent the stages %v", stages)
+	}
+}
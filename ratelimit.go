@@ -0,0 +1,178 @@
+/*
+thehive5 implements functionality to interact with the most recent version of thehive.
+https://www.strangebee.com/thehive/
+*/
+package thehive5
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A Limiter throttles the requests sent to thehive5
+// It combines a token bucket rate limiter with a cap on the number of requests in flight.
+// Set it on Hivedata.Limiter, it is shared by all copies of the client
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	slots  chan struct{}
+
+	// metrics
+	requests  int64
+	queued    int
+	inFlight  int
+	totalWait time.Duration
+	maxWait   time.Duration
+}
+
+// LimiterStats contains the metrics collected by a Limiter
+type LimiterStats struct {
+	Requests  int64         // requests that passed the limiter
+	Queued    int           // requests currently waiting
+	InFlight  int           // requests currently being sent
+	TotalWait time.Duration // time spent waiting by all requests
+	MaxWait   time.Duration // longest time a single request had to wait
+}
+
+// AverageWait returns the mean queueing time per request
+func (s LimiterStats) AverageWait() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.TotalWait / time.Duration(s.Requests)
+}
+
+// NewLimiter creates a new Limiter
+// requestsPerSecond <= 0 disables the rate limit, maxInFlight <= 0 disables the concurrency cap.
+// burst is the number of requests that may be sent at once before throttling starts
+func NewLimiter(requestsPerSecond float64, burst int, maxInFlight int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	l := &Limiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+
+	if maxInFlight > 0 {
+		l.slots = make(chan struct{}, maxInFlight)
+	}
+
+	return l
+}
+
+// Stats returns a snapshot of the collected metrics
+func (l *Limiter) Stats() LimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return LimiterStats{
+		Requests:  l.requests,
+		Queued:    l.queued,
+		InFlight:  l.inFlight,
+		TotalWait: l.totalWait,
+		MaxWait:   l.maxWait,
+	}
+}
+
+// acquire blocks until the request is allowed to be sent
+// The returned function must be called once the request is done
+func (l *Limiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	start := time.Now()
+	l.mu.Lock()
+	l.queued++
+	l.mu.Unlock()
+
+	err := l.waitToken(ctx)
+	if err == nil && l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			// the token isn't used as the request is never sent
+			l.returnToken()
+			err = ctx.Err()
+		}
+	}
+
+	waited := time.Since(start)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.queued--
+	if err != nil {
+		return nil, err
+	}
+
+	l.requests++
+	l.inFlight++
+	l.totalWait += waited
+	if waited > l.maxWait {
+		l.maxWait = waited
+	}
+
+	return l.release, nil
+}
+
+// release frees the slot taken by acquire
+func (l *Limiter) release() {
+	if l.slots != nil {
+		<-l.slots
+	}
+
+	l.mu.Lock()
+	l.inFlight--
+	l.mu.Unlock()
+}
+
+// waitToken reserves a token of the bucket and sleeps until it becomes available
+func (l *Limiter) waitToken(ctx context.Context) error {
+	if l.rate <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.returnToken()
+		return ctx.Err()
+	}
+}
+
+// returnToken hands a token reserved by waitToken back to the bucket
+func (l *Limiter) returnToken() {
+	if l.rate <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	l.tokens++
+	l.mu.Unlock()
+}
@@ -0,0 +1,188 @@
+package thehive5
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it is true or a second has passed
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not reached within a second")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLimiterBurstAndRate(t *testing.T) {
+	l := NewLimiter(20, 3, 0)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		release, err := l.acquire(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	if elapsed := time.Since(start); elapsed > 30*time.Millisecond {
+		t.Fatalf("burst of 3 took %v", elapsed)
+	}
+
+	// the bucket is empty, 4 more requests at 20/s take about 200ms
+	start = time.Now()
+	for i := 0; i < 4; i++ {
+		release, err := l.acquire(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("4 throttled requests took %v, want about 200ms", elapsed)
+	}
+}
+
+func TestLimiterInFlightCap(t *testing.T) {
+	l := NewLimiter(0, 1, 2)
+	ctx := context.Background()
+
+	first, err := l.acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := l.acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan func())
+	go func() {
+		release, err := l.acquire(ctx)
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- release
+	}()
+
+	waitFor(t, func() bool { return l.Stats().Queued == 1 })
+	if stats := l.Stats(); stats.InFlight != 2 {
+		t.Fatalf("InFlight = %d, want 2", stats.InFlight)
+	}
+	select {
+	case <-acquired:
+		t.Fatal("third request passed the in-flight cap")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	first()
+	third := <-acquired
+	if stats := l.Stats(); stats.InFlight != 2 || stats.Queued != 0 {
+		t.Fatalf("after release InFlight = %d Queued = %d, want 2 and 0", stats.InFlight, stats.Queued)
+	}
+
+	second()
+	third()
+	if stats := l.Stats(); stats.InFlight != 0 || stats.Requests != 3 {
+		t.Fatalf("InFlight = %d Requests = %d, want 0 and 3", stats.InFlight, stats.Requests)
+	}
+}
+
+func TestLimiterCancelWhileWaitingForToken(t *testing.T) {
+	l := NewLimiter(1, 1, 0)
+
+	release, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire returned %v, want context.DeadlineExceeded", err)
+	}
+
+	l.mu.Lock()
+	tokens := l.tokens
+	l.mu.Unlock()
+	// the reserved token was handed back, only the refill of ~20ms is left
+	if tokens < -0.1 || tokens > 0.1 {
+		t.Fatalf("tokens = %v after cancellation, want about 0", tokens)
+	}
+
+	stats := l.Stats()
+	if stats.Requests != 1 || stats.Queued != 0 || stats.InFlight != 0 {
+		t.Fatalf("stats after cancellation = %+v", stats)
+	}
+}
+
+func TestLimiterCancelWhileWaitingForSlot(t *testing.T) {
+	l := NewLimiter(10, 5, 1)
+
+	release, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire returned %v, want context.DeadlineExceeded", err)
+	}
+
+	l.mu.Lock()
+	tokens := l.tokens
+	l.mu.Unlock()
+	// 4 tokens are left after the first request, the canceled one must not consume another
+	if tokens < 3.9 {
+		t.Fatalf("tokens = %v after cancellation, the reserved token wasn't returned", tokens)
+	}
+
+	if stats := l.Stats(); stats.Requests != 1 || stats.Queued != 0 || stats.InFlight != 1 {
+		t.Fatalf("stats after cancellation = %+v", stats)
+	}
+}
+
+func TestLimiterStats(t *testing.T) {
+	l := NewLimiter(50, 1, 0)
+	if avg := l.Stats().AverageWait(); avg != 0 {
+		t.Fatalf("AverageWait without requests = %v", avg)
+	}
+
+	for i := 0; i < 3; i++ {
+		release, err := l.acquire(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+
+	stats := l.Stats()
+	if stats.Requests != 3 || stats.Queued != 0 || stats.InFlight != 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	// the second and third request wait about 20ms each
+	if stats.MaxWait < 10*time.Millisecond || stats.TotalWait < stats.MaxWait {
+		t.Fatalf("MaxWait = %v TotalWait = %v", stats.MaxWait, stats.TotalWait)
+	}
+	if avg := stats.AverageWait(); avg != stats.TotalWait/3 {
+		t.Fatalf("AverageWait = %v, want %v", avg, stats.TotalWait/3)
+	}
+}
+
+func TestNilLimiter(t *testing.T) {
+	var l *Limiter
+	release, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+}
@@ -0,0 +1,156 @@
+/*
+thehive5 implements functionality to interact with the most recent version of thehive.
+https://www.strangebee.com/thehive/
+*/
+package thehive5
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A RetryPolicy defines if and how failed requests get sent again
+// Set it on Hivedata.Retry. A nil policy sends every request exactly once
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts
+	MaxBackoff time.Duration
+	// Multiplier is applied to the backoff after every attempt. Defaults to 2
+	Multiplier float64
+	// Jitter randomizes the backoff by +/- the given fraction (0.0 - 1.0)
+	Jitter float64
+	// Methods contains the http methods which are safe to retry (e.g. "GET", "DELETE")
+	Methods []string
+	// RetryQueries allows retrying POST requests to the read-only /api/v1/query endpoint
+	RetryQueries bool
+	// StatusCodes contains the http status codes which are considered transient
+	StatusCodes []int
+	// RetryAfter honours the Retry-After header sent by thehive5 or a load balancer
+	// The wait is capped by MaxBackoff, so a long Retry-After can't block the client
+	RetryAfter bool
+}
+
+// DefaultRetryPolicy returns a policy retrying idempotent requests up to 3 times on 429/502/503/504
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		Methods:        []string{string(GET), string(DELETE)},
+		RetryQueries:   true,
+		StatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryAfter: true,
+	}
+}
+
+// next decides if a failed attempt should be retried and how long to wait before doing so
+func (p *RetryPolicy) next(ctx context.Context, m method, url string, attempt int, err error) (time.Duration, bool) {
+	if p == nil || attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return 0, false
+	}
+
+	if !p.retryableMethod(m, url) {
+		return 0, false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if !p.retryableStatus(apiErr.StatusCode) {
+			return 0, false
+		}
+		if p.RetryAfter && apiErr.RetryAfter > 0 {
+			if p.MaxBackoff > 0 && apiErr.RetryAfter > p.MaxBackoff {
+				return p.MaxBackoff, true
+			}
+			return apiErr.RetryAfter, true
+		}
+	}
+
+	// everything else is a transport error (connection reset, timeout...) which is worth a retry
+	return p.backoff(attempt), true
+}
+
+// retryableMethod checks if the request may be sent again without side effects
+func (p *RetryPolicy) retryableMethod(m method, url string) bool {
+	if m == POST && p.RetryQueries && strings.HasSuffix(url, "/api/v1/query") {
+		return true
+	}
+
+	for _, allowed := range p.Methods {
+		if strings.EqualFold(allowed, string(m)) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryableStatus checks if the status code is part of the transient status codes
+func (p *RetryPolicy) retryableStatus(statusCode int) bool {
+	for _, code := range p.StatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff calculates the exponential delay for the given attempt including jitter
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	wait := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && wait > float64(p.MaxBackoff) {
+		wait = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		wait += wait * p.Jitter * (rand.Float64()*2 - 1)
+	}
+
+	if wait < 0 {
+		return 0
+	}
+	return time.Duration(wait)
+}
+
+// parseRetryAfter converts the value of a Retry-After header into a duration
+// The header is either a number of seconds or a http date
+func parseRetryAfter(value string) time.Duration {
+	if len(value) == 0 {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,199 @@
+package thehive5
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{"first retry", RetryPolicy{InitialBackoff: 100 * time.Millisecond, Multiplier: 2}, 1, 100 * time.Millisecond},
+		{"exponential", RetryPolicy{InitialBackoff: 100 * time.Millisecond, Multiplier: 2}, 3, 400 * time.Millisecond},
+		{"default multiplier", RetryPolicy{InitialBackoff: 100 * time.Millisecond}, 2, 200 * time.Millisecond},
+		{"custom multiplier", RetryPolicy{InitialBackoff: 100 * time.Millisecond, Multiplier: 3}, 3, 900 * time.Millisecond},
+		{"capped", RetryPolicy{InitialBackoff: 100 * time.Millisecond, Multiplier: 2, MaxBackoff: time.Second}, 10, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.backoff(tt.attempt); got != tt.want {
+				t.Fatalf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyJitter(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, Multiplier: 2, Jitter: 0.2}
+
+	varied := false
+	for i := 0; i < 200; i++ {
+		wait := policy.backoff(1)
+		if wait < 80*time.Millisecond || wait > 120*time.Millisecond {
+			t.Fatalf("backoff with 20%% jitter = %v, want 80ms - 120ms", wait)
+		}
+		if wait != 100*time.Millisecond {
+			varied = true
+		}
+	}
+	if !varied {
+		t.Fatal("jitter never changed the backoff")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"", 0, 0},
+		{"3", 3 * time.Second, 3 * time.Second},
+		{"0", 0, 0},
+		{"-5", 0, 0},
+		{"soon", 0, 0},
+		{time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), 59 * time.Minute, time.Hour},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got < tt.min || got > tt.max {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v - %v", tt.value, got, tt.min, tt.max)
+		}
+	}
+}
+
+// failingServer answers every request with status until failures requests were made, then with 200
+func failingServer(t *testing.T, status int, retryAfter string, failures int32) (*httptest.Server, *int32) {
+	t.Helper()
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) > failures {
+			w.Write([]byte(`{}`))
+			return
+		}
+		if len(retryAfter) != 0 {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`{"type":"Unavailable","message":"try again"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func fastRetryPolicy() *RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 5 * time.Millisecond
+	return policy
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       method
+		path         string
+		status       int
+		retryAfter   string
+		failures     int32
+		policy       func(*RetryPolicy)
+		wantRequests int32
+		wantErr      bool
+	}{
+		{name: "GET is retried", method: GET, path: "/api/v1/case/1", status: 503, failures: 100, wantRequests: 3, wantErr: true},
+		{name: "GET succeeds on retry", method: GET, path: "/api/v1/case/1", status: 503, failures: 1, wantRequests: 2},
+		{name: "DELETE is retried", method: DELETE, path: "/api/v1/case/1", status: 502, failures: 100, wantRequests: 3, wantErr: true},
+		{name: "POST is not retried", method: POST, path: "/api/v1/case", status: 503, failures: 100, wantRequests: 1, wantErr: true},
+		{name: "PATCH is not retried", method: PATCH, path: "/api/v1/case/1", status: 503, failures: 100, wantRequests: 1, wantErr: true},
+		{name: "POST query is retried", method: POST, path: "/api/v1/query", status: 503, failures: 100, wantRequests: 3, wantErr: true},
+		{
+			name: "POST query without RetryQueries", method: POST, path: "/api/v1/query", status: 503, failures: 100,
+			policy: func(p *RetryPolicy) { p.RetryQueries = false }, wantRequests: 1, wantErr: true,
+		},
+		{name: "429 with Retry-After is capped by MaxBackoff", method: GET, path: "/api/v1/case/1", status: 429, retryAfter: "120", failures: 100, wantRequests: 3, wantErr: true},
+		{name: "404 is not transient", method: GET, path: "/api/v1/case/1", status: 404, failures: 100, wantRequests: 1, wantErr: true},
+		{
+			name: "MaxAttempts is respected", method: GET, path: "/api/v1/case/1", status: 503, failures: 100,
+			policy: func(p *RetryPolicy) { p.MaxAttempts = 5 }, wantRequests: 5, wantErr: true,
+		},
+		{
+			name: "single attempt", method: GET, path: "/api/v1/case/1", status: 503, failures: 100,
+			policy: func(p *RetryPolicy) { p.MaxAttempts = 1 }, wantRequests: 1, wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := failingServer(t, tt.status, tt.retryAfter, tt.failures)
+			policy := fastRetryPolicy()
+			if tt.policy != nil {
+				tt.policy(policy)
+			}
+			hive, err := NewClient(srv.URL, WithAPIKey("key"), WithRetryPolicy(policy))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			start := time.Now()
+			_, err = hive.webRequest(context.Background(), srv.URL+tt.path, tt.method, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("webRequest error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(requests); got != tt.wantRequests {
+				t.Fatalf("sent %d requests, want %d", got, tt.wantRequests)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("retries took %v", elapsed)
+			}
+
+			var apiErr *APIError
+			if tt.wantErr && (!errors.As(err, &apiErr) || apiErr.StatusCode != tt.status) {
+				t.Fatalf("error %v is no APIError with status %d", err, tt.status)
+			}
+		})
+	}
+}
+
+func TestRetryCanceledDuringBackoff(t *testing.T) {
+	srv, requests := failingServer(t, http.StatusServiceUnavailable, "10", 100)
+	policy := DefaultRetryPolicy()
+	policy.MaxBackoff = time.Minute
+	hive, err := NewClient(srv.URL, WithAPIKey("key"), WithRetryPolicy(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = hive.webRequest(ctx, srv.URL+"/api/v1/case/1", GET, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error %v doesn't match context.DeadlineExceeded", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("error %v doesn't contain the last APIError", err)
+	}
+	if !strings.Contains(err.Error(), "try again") {
+		t.Fatalf("error %q doesn't mention the last failure", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("waited %v despite the deadline", elapsed)
+	}
+	if got := atomic.LoadInt32(requests); got != 1 {
+		t.Fatalf("sent %d requests, want 1", got)
+	}
+}
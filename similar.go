@@ -0,0 +1,93 @@
+/*
+thehive5 implements functionality to interact with the most recent version of thehive.
+https://www.strangebee.com/thehive/
+*/
+package thehive5
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+)
+
+// Similarity contains the observable overlap between two objects
+type Similarity struct {
+	// SimilarObservableCount is the number of shared observables
+	SimilarObservableCount int `json:"similarObservableCount"`
+	// ObservableCount is the number of observables of the similar object
+	ObservableCount int `json:"observableCount"`
+	// SimilarIocCount is the number of shared observables flagged as ioc
+	SimilarIocCount int `json:"similarIocCount"`
+	// IocCount is the number of observables flagged as ioc of the similar object
+	IocCount int `json:"iocCount"`
+	// ObservableTypes counts the shared observables per data type
+	ObservableTypes map[string]int `json:"observableTypes"`
+}
+
+// A SimilarCase is a case sharing observables with an alert
+type SimilarCase struct {
+	Case HiveCaseResponse `json:"case"`
+	Similarity
+}
+
+// A SimilarAlert is an alert sharing observables with a case
+type SimilarAlert struct {
+	Alert HiveAlertResponse `json:"alert"`
+	Similarity
+}
+
+// GetSimilarCases returns the cases sharing observables with an alert
+func (hive *Hivedata) GetSimilarCases(alertId string) ([]SimilarCase, error) {
+	return hive.GetSimilarCasesContext(context.Background(), alertId)
+}
+
+// GetSimilarCasesContext is like GetSimilarCases but uses ctx for the request
+func (hive *Hivedata) GetSimilarCasesContext(ctx context.Context, alertId string) ([]SimilarCase, error) {
+	ret, err := hive.executeSimilarQuery(ctx,
+		SearchQuery{Name: "getAlert", IdOrName: alertId},
+		SearchQuery{Name: "similarCases"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsedRet []SimilarCase
+	err = json.Unmarshal(ret, &parsedRet)
+	return parsedRet, err
+}
+
+// GetSimilarAlerts returns the alerts sharing observables with a case
+func (hive *Hivedata) GetSimilarAlerts(caseId int) ([]SimilarAlert, error) {
+	return hive.GetSimilarAlertsContext(context.Background(), caseId)
+}
+
+// GetSimilarAlertsContext is like GetSimilarAlerts but uses ctx for the request
+func (hive *Hivedata) GetSimilarAlertsContext(ctx context.Context, caseId int) ([]SimilarAlert, error) {
+	ret, err := hive.executeSimilarQuery(ctx,
+		SearchQuery{Name: "getCase", IdOrName: strconv.Itoa(caseId)},
+		SearchQuery{Name: "similarAlerts"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsedRet []SimilarAlert
+	err = json.Unmarshal(ret, &parsedRet)
+	return parsedRet, err
+}
+
+// executeSimilarQuery sends the query and returns the raw response
+func (hive *Hivedata) executeSimilarQuery(ctx context.Context, stages ...SearchQuery) ([]byte, error) {
+	query, err := hive.createSearchQuery(stages...)
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := url.JoinPath(hive.Url, "/api/v1/query")
+	if err != nil {
+		return nil, err
+	}
+
+	return hive.webRequest(ctx, url, POST, query)
+}
@@ -0,0 +1,58 @@
+package thehive5_test
+
+import (
+	"testing"
+
+	thehive5 "github.com/b401/goHive5"
+	"github.com/b401/goHive5/thehive5test"
+)
+
+func createAlert(t *testing.T, hive *thehive5.Hivedata, sourceRef string, observables ...thehive5.Observable) *thehive5.HiveAlertResponse {
+	t.Helper()
+	alert, err := hive.CreateAlert(&thehive5.HiveAlert{
+		Type:        "external",
+		Source:      "test",
+		SourceRef:   sourceRef,
+		Title:       "alert " + sourceRef,
+		Description: "description",
+		Observables: &observables,
+	})
+	if err != nil {
+		t.Fatalf("CreateAlert: %v", err)
+	}
+	return alert
+}
+
+func TestSimilar(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	hive := srv.Hive()
+	c := createCases(t, hive, "high")[0]
+	ioc := thehive5.Observable{DataType: "domain", Data: "evil.example", Ioc: true}
+	if err := hive.AddCaseObservable(c.Number, &ioc); err != nil {
+		t.Fatalf("AddCaseObservable: %v", err)
+	}
+
+	alert := createAlert(t, hive, "1",
+		thehive5.Observable{DataType: "domain", Data: "evil.example", Ioc: true},
+		thehive5.Observable{DataType: "ip", Data: "10.0.0.2"},
+	)
+	createAlert(t, hive, "2", thehive5.Observable{DataType: "ip", Data: "10.0.0.3"})
+
+	similarCases, err := hive.GetSimilarCases(alert.Id)
+	if err != nil {
+		t.Fatalf("GetSimilarCases: %v", err)
+	}
+	if len(similarCases) != 1 || similarCases[0].Case.Number != c.Number ||
+		similarCases[0].SimilarObservableCount != 1 || similarCases[0].SimilarIocCount != 1 {
+		t.Fatalf("GetSimilarCases = %+v", similarCases)
+	}
+
+	similarAlerts, err := hive.GetSimilarAlerts(c.Number)
+	if err != nil {
+		t.Fatalf("GetSimilarAlerts: %v", err)
+	}
+	if len(similarAlerts) != 1 || similarAlerts[0].Alert.Id != alert.Id || similarAlerts[0].ObservableTypes["domain"] != 1 {
+		t.Fatalf("GetSimilarAlerts = %+v", similarAlerts)
+	}
+}
@@ -0,0 +1,306 @@
+/*
+thehive5 implements functionality to interact with the most recent version of thehive.
+https://www.strangebee.com/thehive/
+*/
+package thehive5
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// An Aggregation describes an aggregation stage of the query endpoint
+// It gets inlined into a SearchQuery with the name "aggregation"
+type Aggregation struct {
+	Agg      string        `json:"_agg"`
+	Field    string        `json:"_field,omitempty"`
+	Fields   []string      `json:"_fields,omitempty"`
+	Interval string        `json:"_interval,omitempty"`
+	Order    []string      `json:"_order,omitempty"`
+	Size     int           `json:"_size,omitempty"`
+	Select   []Aggregation `json:"_select,omitempty"`
+}
+
+// A TermBucket contains the number of objects sharing the same value of a field
+type TermBucket struct {
+	Key   string
+	Count int64
+}
+
+// A DateBucket contains the number of objects within a time interval
+// Terms is only set by DateHistogramByField
+type DateBucket struct {
+	Date  time.Time
+	Count int64
+	Terms []TermBucket
+}
+
+// FieldStats contains the average, minimum and maximum of a numeric field
+type FieldStats struct {
+	Avg float64
+	Min float64
+	Max float64
+}
+
+// AvgDuration returns the average of a duration field like timeToResolve
+func (f FieldStats) AvgDuration() time.Duration {
+	return convertInt64ToDuration(int64(f.Avg))
+}
+
+// MinDuration returns the minimum of a duration field like timeToResolve
+func (f FieldStats) MinDuration() time.Duration {
+	return convertInt64ToDuration(int64(f.Min))
+}
+
+// MaxDuration returns the maximum of a duration field like timeToResolve
+func (f FieldStats) MaxDuration() time.Duration {
+	return convertInt64ToDuration(int64(f.Max))
+}
+
+// Aggregate appends an aggregation stage to the query and returns the raw response
+func (hive *Hivedata) Aggregate(searchQuery []SearchQuery, aggregation Aggregation) (json.RawMessage, error) {
+	return hive.AggregateContext(context.Background(), searchQuery, aggregation)
+}
+
+// AggregateContext is like Aggregate but uses ctx for the request
+func (hive *Hivedata) AggregateContext(ctx context.Context, searchQuery []SearchQuery, aggregation Aggregation) (json.RawMessage, error) {
+	stages := append([]SearchQuery{}, searchQuery...)
+	stages = append(stages, SearchQuery{Name: "aggregation", Aggregation: &aggregation})
+
+	query, err := hive.createSearchQuery(stages...)
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := url.JoinPath(hive.Url, "/api/v1/query")
+	if err != nil {
+		return nil, err
+	}
+
+	return hive.webRequest(ctx, url, POST, query)
+}
+
+// CountByField counts the objects of the query per value of field (e.g. severity, status, assignee, tags)
+// size limits the number of buckets, 0 uses the server default
+// The buckets are sorted by count in descending order
+func (hive *Hivedata) CountByField(searchQuery []SearchQuery, field string, size int) ([]TermBucket, error) {
+	return hive.CountByFieldContext(context.Background(), searchQuery, field, size)
+}
+
+// CountByFieldContext is like CountByField but uses ctx for the request
+func (hive *Hivedata) CountByFieldContext(ctx context.Context, searchQuery []SearchQuery, field string, size int) ([]TermBucket, error) {
+	ret, err := hive.AggregateContext(ctx, searchQuery, Aggregation{
+		Agg:    "field",
+		Field:  field,
+		Order:  []string{"-count"},
+		Size:   size,
+		Select: []Aggregation{{Agg: "count"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTermBuckets(ret)
+}
+
+// DateHistogram counts the objects of the query per interval of dateField
+// interval uses thehive5 syntax, e.g. "1d", "1w" or "1M"
+func (hive *Hivedata) DateHistogram(searchQuery []SearchQuery, dateField string, interval string) ([]DateBucket, error) {
+	return hive.DateHistogramContext(context.Background(), searchQuery, dateField, interval)
+}
+
+// DateHistogramContext is like DateHistogram but uses ctx for the request
+func (hive *Hivedata) DateHistogramContext(ctx context.Context, searchQuery []SearchQuery, dateField string, interval string) ([]DateBucket, error) {
+	ret, err := hive.AggregateContext(ctx, searchQuery, Aggregation{
+		Agg:      "time",
+		Fields:   []string{dateField},
+		Interval: interval,
+		Select:   []Aggregation{{Agg: "count"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDateBuckets(ret, dateField, "")
+}
+
+// DateHistogramByField is like DateHistogram but splits every interval by the values of field
+// e.g. alerts per source and week
+func (hive *Hivedata) DateHistogramByField(searchQuery []SearchQuery, dateField string, interval string, field string) ([]DateBucket, error) {
+	return hive.DateHistogramByFieldContext(context.Background(), searchQuery, dateField, interval, field)
+}
+
+// DateHistogramByFieldContext is like DateHistogramByField but uses ctx for the request
+func (hive *Hivedata) DateHistogramByFieldContext(ctx context.Context, searchQuery []SearchQuery, dateField string, interval string, field string) ([]DateBucket, error) {
+	ret, err := hive.AggregateContext(ctx, searchQuery, Aggregation{
+		Agg:      "time",
+		Fields:   []string{dateField},
+		Interval: interval,
+		Select: []Aggregation{{
+			Agg:    "field",
+			Field:  field,
+			Select: []Aggregation{{Agg: "count"}},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDateBuckets(ret, dateField, field)
+}
+
+// GetFieldStats returns the average, minimum and maximum of a numeric field of the query
+// Duration fields like timeToResolve can be read with FieldStats.AvgDuration
+func (hive *Hivedata) GetFieldStats(searchQuery []SearchQuery, field string) (*FieldStats, error) {
+	return hive.GetFieldStatsContext(context.Background(), searchQuery, field)
+}
+
+// GetFieldStatsContext is like GetFieldStats but uses ctx for the request
+func (hive *Hivedata) GetFieldStatsContext(ctx context.Context, searchQuery []SearchQuery, field string) (*FieldStats, error) {
+	// metrics can only be selected within buckets. The query returns a single type of objects,
+	// so grouping by _type puts all of them into one bucket
+	ret, err := hive.AggregateContext(ctx, searchQuery, Aggregation{
+		Agg:   "field",
+		Field: "_type",
+		Select: []Aggregation{
+			{Agg: "avg", Field: field},
+			{Agg: "min", Field: field},
+			{Agg: "max", Field: field},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buckets map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(ret, &buckets); err != nil {
+		return nil, err
+	}
+
+	stats := &FieldStats{}
+	if len(buckets) == 0 {
+		// the query didn't return any objects
+		return stats, nil
+	}
+	if len(buckets) != 1 {
+		return nil, fmt.Errorf("expected the objects of a single type in aggregation response: %s", string(ret))
+	}
+
+	for _, bucket := range buckets {
+		metrics := []struct {
+			agg    string
+			target *float64
+		}{
+			{"avg", &stats.Avg},
+			{"min", &stats.Min},
+			{"max", &stats.Max},
+		}
+		for _, metric := range metrics {
+			key := metric.agg + "_" + field
+			value, ok := bucket[key]
+			if !ok {
+				return nil, fmt.Errorf("no %s value found in aggregation response: %s", key, string(ret))
+			}
+			if err := json.Unmarshal(value, metric.target); err != nil {
+				return nil, fmt.Errorf("invalid %s value in aggregation response: %w", key, err)
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+// parseTermBuckets converts {"value": {"count": n}, ...} into buckets
+func parseTermBuckets(data []byte) ([]TermBucket, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	buckets := []TermBucket{}
+	for key, value := range raw {
+		count, err := parseCount(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bucket %q in aggregation response: %w", key, err)
+		}
+		buckets = append(buckets, TermBucket{Key: key, Count: count})
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].Count == buckets[j].Count {
+			return buckets[i].Key < buckets[j].Key
+		}
+		return buckets[i].Count > buckets[j].Count
+	})
+
+	return buckets, nil
+}
+
+// parseDateBuckets converts {"<unixmilli>": {"<dateField>": {...}}, ...} into buckets
+// The value of dateField is a count, or term buckets if field is set
+func parseDateBuckets(data []byte, dateField string, field string) ([]DateBucket, error) {
+	var raw map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	buckets := []DateBucket{}
+	for key, inner := range raw {
+		timestamp, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date bucket %q in aggregation response", key)
+		}
+
+		value, ok := inner[dateField]
+		if !ok {
+			return nil, fmt.Errorf("date bucket %q contains no %s", key, dateField)
+		}
+
+		bucket := DateBucket{Date: convertInt64ToTime(timestamp)}
+		if len(field) != 0 {
+			terms, err := parseTermBuckets(value)
+			if err != nil {
+				return nil, err
+			}
+			bucket.Terms = terms
+			for _, term := range terms {
+				bucket.Count += term.Count
+			}
+		} else {
+			bucket.Count, err = parseCount(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid date bucket %q in aggregation response: %w", key, err)
+			}
+		}
+
+		buckets = append(buckets, bucket)
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Date.Before(buckets[j].Date)
+	})
+
+	return buckets, nil
+}
+
+// parseCount reads the result of a count aggregation: {"count": n}
+func parseCount(data []byte) (int64, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return 0, err
+	}
+
+	countRaw, ok := raw["count"]
+	if !ok {
+		return 0, fmt.Errorf("no count found in %s", string(data))
+	}
+
+	var count int64
+	err := json.Unmarshal(countRaw, &count)
+	return count, err
+}
@@ -0,0 +1,60 @@
+package thehive5_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	thehive5 "github.com/b401/goHive5"
+	"github.com/b401/goHive5/thehive5test"
+)
+
+func TestAggregations(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	hive := srv.Hive()
+	createCases(t, hive, "low", "high", "high", "critical")
+	cases := thehive5.Cases().Build()
+
+	terms, err := hive.CountByField(cases, "severity", 0)
+	if err != nil {
+		t.Fatalf("CountByField: %v", err)
+	}
+	want := []thehive5.TermBucket{{Key: "3", Count: 2}, {Key: "1", Count: 1}, {Key: "4", Count: 1}}
+	if fmt.Sprint(terms) != fmt.Sprint(want) {
+		t.Fatalf("CountByField = %v, want %v", terms, want)
+	}
+
+	histogram, err := hive.DateHistogram(cases, "_createdAt", "1d")
+	if err != nil {
+		t.Fatalf("DateHistogram: %v", err)
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if len(histogram) != 1 || histogram[0].Count != 4 || !histogram[0].Date.Equal(today) {
+		t.Fatalf("DateHistogram = %v, want 4 cases on %v", histogram, today)
+	}
+
+	byField, err := hive.DateHistogramByField(cases, "_createdAt", "1M", "severity")
+	if err != nil {
+		t.Fatalf("DateHistogramByField: %v", err)
+	}
+	if len(byField) != 1 || byField[0].Count != 4 || fmt.Sprint(byField[0].Terms) != fmt.Sprint(want) {
+		t.Fatalf("DateHistogramByField = %v", byField)
+	}
+	if day := byField[0].Date.UTC(); day.Day() != 1 || day.Month() != today.Month() {
+		t.Fatalf("monthly bucket starts at %v", day)
+	}
+
+	stats, err := hive.GetFieldStats(cases, "severity")
+	if err != nil {
+		t.Fatalf("GetFieldStats: %v", err)
+	}
+	if stats.Avg != 2.75 || stats.Min != 1 || stats.Max != 4 {
+		t.Fatalf("GetFieldStats = %+v", stats)
+	}
+
+	empty, err := hive.GetFieldStats(thehive5.Cases().Where(thehive5.Eq("title", "none")).Build(), "severity")
+	if err != nil || *empty != (thehive5.FieldStats{}) {
+		t.Fatalf("GetFieldStats without cases = %+v, %v", empty, err)
+	}
+}
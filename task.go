@@ -5,6 +5,7 @@ https://www.strangebee.com/thehive/
 package thehive5
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"strconv"
@@ -230,13 +231,13 @@ func (ct *CaseTaskResponse) UnmarshalJSON(data []byte) error {
 }
 
 // executeTaskSearchQuery is a helper function to do query related searches
-func (hive *Hivedata) executeTaskSearchQuery(query []byte) ([]CaseTaskResponse, error) {
+func (hive *Hivedata) executeTaskSearchQuery(ctx context.Context, query []byte) ([]CaseTaskResponse, error) {
 	url, err := url.JoinPath(hive.Url, "/api/v1/query")
 	if err != nil {
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, POST, query)
+	ret, err := hive.webRequest(ctx, url, POST, query)
 
 	if err != nil {
 		return nil, err
@@ -248,13 +249,13 @@ func (hive *Hivedata) executeTaskSearchQuery(query []byte) ([]CaseTaskResponse,
 }
 
 // executeTaskLogSearchQuery is a helper function to do query related searches
-func (hive *Hivedata) executeTaskLogSearchQuery(query []byte) ([]TaskLogResponse, error) {
+func (hive *Hivedata) executeTaskLogSearchQuery(ctx context.Context, query []byte) ([]TaskLogResponse, error) {
 	url, err := url.JoinPath(hive.Url, "/api/v1/query")
 	if err != nil {
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, POST, query)
+	ret, err := hive.webRequest(ctx, url, POST, query)
 
 	if err != nil {
 		return nil, err
@@ -268,6 +269,11 @@ func (hive *Hivedata) executeTaskLogSearchQuery(query []byte) ([]TaskLogResponse
 // UpdateTask updates an existing task
 // Only returns data if an error occured
 func (hive *Hivedata) UpdateTask(taskId string, task *CaseTask) error {
+	return hive.UpdateTaskContext(context.Background(), taskId, task)
+}
+
+// UpdateTaskContext is like UpdateTask but uses ctx for the request
+func (hive *Hivedata) UpdateTaskContext(ctx context.Context, taskId string, task *CaseTask) error {
 	url, err := url.JoinPath(hive.Url, "/api/v1/task/", taskId)
 	if err != nil {
 		return err
@@ -278,12 +284,17 @@ func (hive *Hivedata) UpdateTask(taskId string, task *CaseTask) error {
 		return err
 	}
 
-	_, err = hive.webRequest(url, PATCH, jsondata)
+	_, err = hive.webRequest(ctx, url, PATCH, jsondata)
 	return err
 }
 
 // AddTaskToCase creates a new task and adds to an existing case
 func (hive *Hivedata) AddTaskToCase(caseId int, task *CaseTask) (*CaseTaskResponse, error) {
+	return hive.AddTaskToCaseContext(context.Background(), caseId, task)
+}
+
+// AddTaskToCaseContext is like AddTaskToCase but uses ctx for the request
+func (hive *Hivedata) AddTaskToCaseContext(ctx context.Context, caseId int, task *CaseTask) (*CaseTaskResponse, error) {
 	url, err := url.JoinPath(hive.Url, "/api/v1/case/", strconv.Itoa(caseId), "/task")
 	if err != nil {
 		return nil, err
@@ -294,7 +305,7 @@ func (hive *Hivedata) AddTaskToCase(caseId int, task *CaseTask) (*CaseTaskRespon
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, POST, jsondata)
+	ret, err := hive.webRequest(ctx, url, POST, jsondata)
 	if err != nil {
 		return nil, err
 	}
@@ -310,24 +321,34 @@ func (hive *Hivedata) AddTaskToCase(caseId int, task *CaseTask) (*CaseTaskRespon
 // DeleteTask deletes an existing task from a case
 // only returns data if an error occured
 func (hive *Hivedata) DeleteTask(taskId string) error {
+	return hive.DeleteTaskContext(context.Background(), taskId)
+}
+
+// DeleteTaskContext is like DeleteTask but uses ctx for the request
+func (hive *Hivedata) DeleteTaskContext(ctx context.Context, taskId string) error {
 	url, err := url.JoinPath(hive.Url, "/api/v1/task/", taskId)
 	if err != nil {
 		return err
 	}
 
-	_, err = hive.webRequest(url, DELETE, nil)
+	_, err = hive.webRequest(ctx, url, DELETE, nil)
 	return err
 }
 
 // GetTask returns a single CaseTaskResponse object or error
 // A task ID must be provided
 func (hive *Hivedata) GetTask(taskId string) (*CaseTaskResponse, error) {
+	return hive.GetTaskContext(context.Background(), taskId)
+}
+
+// GetTaskContext is like GetTask but uses ctx for the request
+func (hive *Hivedata) GetTaskContext(ctx context.Context, taskId string) (*CaseTaskResponse, error) {
 	url, err := url.JoinPath(hive.Url, "/api/v1/task/", taskId)
 	if err != nil {
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, GET, nil)
+	ret, err := hive.webRequest(ctx, url, GET, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -343,6 +364,11 @@ func (hive *Hivedata) GetTask(taskId string) (*CaseTaskResponse, error) {
 // GetTaskLog returns all log entries of a task
 // A task ID must be provided
 func (hive *Hivedata) CreateTaskLog(taskId string, log *TaskLog) (*TaskLogResponse, error) {
+	return hive.CreateTaskLogContext(context.Background(), taskId, log)
+}
+
+// CreateTaskLogContext is like CreateTaskLog but uses ctx for the request
+func (hive *Hivedata) CreateTaskLogContext(ctx context.Context, taskId string, log *TaskLog) (*TaskLogResponse, error) {
 	url, err := url.JoinPath(hive.Url, "/api/v1/task/", taskId, "/log")
 	if err != nil {
 		return nil, err
@@ -353,7 +379,7 @@ func (hive *Hivedata) CreateTaskLog(taskId string, log *TaskLog) (*TaskLogRespon
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, POST, jsondata)
+	ret, err := hive.webRequest(ctx, url, POST, jsondata)
 	if err != nil {
 		return nil, err
 	}
@@ -369,6 +395,11 @@ func (hive *Hivedata) CreateTaskLog(taskId string, log *TaskLog) (*TaskLogRespon
 // GetTaskLogs returns all logs associated with a task
 // It returns a task log slice or an error
 func (hive *Hivedata) GetTaskLogs(taskId string) ([]TaskLogResponse, error) {
+	return hive.GetTaskLogsContext(context.Background(), taskId)
+}
+
+// GetTaskLogsContext is like GetTaskLogs but uses ctx for the request
+func (hive *Hivedata) GetTaskLogsContext(ctx context.Context, taskId string) ([]TaskLogResponse, error) {
 	query, err := hive.createSearchQuery(
 		SearchQuery{Name: "getTask", IdOrName: taskId},
 		SearchQuery{Name: "logs"},
@@ -377,12 +408,17 @@ func (hive *Hivedata) GetTaskLogs(taskId string) ([]TaskLogResponse, error) {
 		return nil, err
 	}
 
-	return hive.executeTaskLogSearchQuery(query)
+	return hive.executeTaskLogSearchQuery(ctx, query)
 }
 
 // GetCaseTasks returns all tasks associated with a case
 // It returns a task slice or an error
 func (hive *Hivedata) GetCaseTasks(caseId int) ([]CaseTaskResponse, error) {
+	return hive.GetCaseTasksContext(context.Background(), caseId)
+}
+
+// GetCaseTasksContext is like GetCaseTasks but uses ctx for the request
+func (hive *Hivedata) GetCaseTasksContext(ctx context.Context, caseId int) ([]CaseTaskResponse, error) {
 	caseNumber := strconv.Itoa(caseId)
 	query, err := hive.createSearchQuery(
 		SearchQuery{Name: "getCase", IdOrName: caseNumber},
@@ -392,5 +428,5 @@ func (hive *Hivedata) GetCaseTasks(caseId int) ([]CaseTaskResponse, error) {
 		return nil, err
 	}
 
-	return hive.executeTaskSearchQuery(query)
+	return hive.executeTaskSearchQuery(ctx, query)
 }
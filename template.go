@@ -5,6 +5,7 @@ https://www.strangebee.com/thehive/
 package thehive5
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"time"
@@ -145,12 +146,17 @@ func (ctr *CaseTemplateResponse) UnmarshalJSON(data []byte) error {
 // GetCaseTemplate looks up a specific template on thehive5 instance.
 // It returns the CaseTemplateResponse of an error on failure
 func (hive *Hivedata) GetCaseTemplate(templateName string) (*CaseTemplateResponse, error) {
+	return hive.GetCaseTemplateContext(context.Background(), templateName)
+}
+
+// GetCaseTemplateContext is like GetCaseTemplate but uses ctx for the request
+func (hive *Hivedata) GetCaseTemplateContext(ctx context.Context, templateName string) (*CaseTemplateResponse, error) {
 	url, err := url.JoinPath(hive.Url, "/api/v1/caseTemplate/", templateName)
 	if err != nil {
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, GET, nil)
+	ret, err := hive.webRequest(ctx, url, GET, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -163,18 +169,28 @@ func (hive *Hivedata) GetCaseTemplate(templateName string) (*CaseTemplateRespons
 // DeleteCaseTemplate allows the deletion of templates on thehive5 instance.
 // it returns an error only if the deletion failed.
 func (hive *Hivedata) DeleteCaseTemplate(templateName string) error {
+	return hive.DeleteCaseTemplateContext(context.Background(), templateName)
+}
+
+// DeleteCaseTemplateContext is like DeleteCaseTemplate but uses ctx for the request
+func (hive *Hivedata) DeleteCaseTemplateContext(ctx context.Context, templateName string) error {
 	url, err := url.JoinPath(hive.Url, "/api/v1/caseTemplate/", templateName)
 	if err != nil {
 		return err
 	}
 
-	_, err = hive.webRequest(url, DELETE, nil)
+	_, err = hive.webRequest(ctx, url, DELETE, nil)
 	return err
 }
 
 // UpdateCaseTemplate updates an already existing template on thehive5.
 // Submit the template name as first argument and a CaseTemplate object with the attributes you want to overwrite as the second.
 func (hive *Hivedata) UpdateCaseTemplate(templateName string, updatedTemplate CaseTemplate) error {
+	return hive.UpdateCaseTemplateContext(context.Background(), templateName, updatedTemplate)
+}
+
+// UpdateCaseTemplateContext is like UpdateCaseTemplate but uses ctx for the request
+func (hive *Hivedata) UpdateCaseTemplateContext(ctx context.Context, templateName string, updatedTemplate CaseTemplate) error {
 	url, err := url.JoinPath(hive.Url, "/api/v1/caseTemplate/", templateName)
 	if err != nil {
 		return err
@@ -185,6 +201,6 @@ func (hive *Hivedata) UpdateCaseTemplate(templateName string, updatedTemplate Ca
 		return err
 	}
 
-	_, err = hive.webRequest(url, PATCH, jsonrequest)
+	_, err = hive.webRequest(ctx, url, PATCH, jsonrequest)
 	return err
 }
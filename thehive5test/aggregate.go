@@ -0,0 +1,171 @@
+package thehive5test
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// aggregate supports the field, time, count, avg, min and max aggregations
+// Buckets of field and time contain the merged results of their _select aggregations, count if there are none
+func (s *Server) aggregate(objects []*object, stage map[string]interface{}) (interface{}, error) {
+	agg, _ := stage["_agg"].(string)
+	field, _ := stage["_field"].(string)
+
+	switch agg {
+	case "count":
+		return map[string]interface{}{"count": len(objects)}, nil
+	case "field":
+		var keys []string
+		groups := map[string][]*object{}
+		for _, obj := range objects {
+			value, ok := getField(obj, field)
+			if !ok {
+				continue
+			}
+			for _, v := range toSlice(value) {
+				key := fmt.Sprint(v)
+				if _, ok := groups[key]; !ok {
+					keys = append(keys, key)
+				}
+				groups[key] = append(groups[key], obj)
+			}
+		}
+
+		buckets := map[string]interface{}{}
+		for _, key := range keys {
+			bucket, err := s.subAggregate(groups[key], stage["_select"])
+			if err != nil {
+				return nil, err
+			}
+			buckets[key] = bucket
+		}
+		return buckets, nil
+	case "time":
+		fields := toSlice(stage["_fields"])
+		if len(fields) == 0 {
+			return nil, badRequest("time aggregation requires _fields")
+		}
+		interval, _ := stage["_interval"].(string)
+
+		buckets := map[string]interface{}{}
+		for _, rawField := range fields {
+			dateField := fmt.Sprint(rawField)
+			groups := map[int64][]*object{}
+			for _, obj := range objects {
+				value, _ := getField(obj, dateField)
+				date, ok := toFloat(value)
+				if !ok {
+					continue
+				}
+				start, err := bucketStart(int64(date), interval)
+				if err != nil {
+					return nil, err
+				}
+				groups[start] = append(groups[start], obj)
+			}
+
+			for start, group := range groups {
+				bucket, err := s.subAggregate(group, stage["_select"])
+				if err != nil {
+					return nil, err
+				}
+				key := strconv.FormatInt(start, 10)
+				dates, _ := buckets[key].(map[string]interface{})
+				if dates == nil {
+					dates = map[string]interface{}{}
+					buckets[key] = dates
+				}
+				dates[dateField] = bucket
+			}
+		}
+		return buckets, nil
+	case "avg", "min", "max":
+		var result float64
+		found := 0
+		for _, obj := range objects {
+			value, _ := getField(obj, field)
+			number, ok := toFloat(value)
+			if !ok {
+				continue
+			}
+			switch {
+			case found == 0 || agg == "avg":
+				if found == 0 {
+					result = number
+				} else {
+					result += number
+				}
+			case agg == "min" && number < result:
+				result = number
+			case agg == "max" && number > result:
+				result = number
+			}
+			found++
+		}
+		if agg == "avg" && found != 0 {
+			result /= float64(found)
+		}
+		return map[string]interface{}{fmt.Sprintf("%s_%s", agg, field): result}, nil
+	}
+
+	return nil, badRequest("aggregation %q is not supported", agg)
+}
+
+// subAggregate runs the _select aggregations of a bucket and merges their results
+func (s *Server) subAggregate(objects []*object, selects interface{}) (map[string]interface{}, error) {
+	stages := toSlice(selects)
+	if len(stages) == 0 {
+		return map[string]interface{}{"count": len(objects)}, nil
+	}
+
+	merged := map[string]interface{}{}
+	for _, rawStage := range stages {
+		stage, ok := rawStage.(map[string]interface{})
+		if !ok {
+			return nil, badRequest("invalid aggregation %v", rawStage)
+		}
+		result, err := s.aggregate(objects, stage)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range result.(map[string]interface{}) {
+			merged[key] = value
+		}
+	}
+	return merged, nil
+}
+
+// bucketStart returns the start of the interval containing date, both in unix milliseconds
+// Intervals use the thehive5 syntax, e.g. 1h, 1d, 1w, 1M or 1y
+func bucketStart(date int64, interval string) (int64, error) {
+	if len(interval) < 2 {
+		return 0, badRequest("invalid interval %q", interval)
+	}
+	n, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil || n <= 0 {
+		return 0, badRequest("invalid interval %q", interval)
+	}
+
+	t := time.UnixMilli(date).UTC()
+	switch interval[len(interval)-1] {
+	case 'M':
+		months := (t.Year()*12 + int(t.Month()) - 1) / n * n
+		return time.Date(months/12, time.Month(months%12+1), 1, 0, 0, 0, 0, time.UTC).UnixMilli(), nil
+	case 'y':
+		return time.Date(t.Year()/n*n, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli(), nil
+	}
+
+	unit, ok := map[byte]time.Duration{
+		's': time.Second,
+		'm': time.Minute,
+		'h': time.Hour,
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+	}[interval[len(interval)-1]]
+	if !ok {
+		return 0, badRequest("invalid interval %q", interval)
+	}
+	size := (time.Duration(n) * unit).Milliseconds()
+	return date - date%size, nil
+}
@@ -0,0 +1,544 @@
+package thehive5test
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// getStages map the stages fetching a single object to its kind
+var getStages = map[string]string{
+	"getCase":       KindCase,
+	"getAlert":      KindAlert,
+	"getTask":       KindTask,
+	"getObservable": KindObservable,
+}
+
+// listStages map the stages listing all objects to their kind
+var listStages = map[string]string{
+	"listCase":       KindCase,
+	"listAlert":      KindAlert,
+	"listTask":       KindTask,
+	"listObservable": KindObservable,
+}
+
+// childStages map the stages listing the objects belonging to the current ones to their kind
+var childStages = map[string]string{
+	"observables": KindObservable,
+	"tasks":       KindTask,
+	"logs":        KindLog,
+	"comments":    KindComment,
+}
+
+// query executes the subset of the query api used by thehive5
+func (s *Server) query(body map[string]interface{}) (interface{}, error) {
+	stages, ok := body["query"].([]interface{})
+	if !ok {
+		return nil, badRequest("query is missing")
+	}
+
+	var current []*object
+	for _, rawStage := range stages {
+		stage, ok := rawStage.(map[string]interface{})
+		if !ok {
+			return nil, badRequest("invalid query stage %v", rawStage)
+		}
+		name, _ := stage["_name"].(string)
+
+		if kind, ok := listStages[name]; ok {
+			current = s.list(kind)
+			continue
+		}
+		if kind, ok := getStages[name]; ok {
+			id, _ := stage["idOrName"].(string)
+			obj, err := s.lookup(kind, id)
+			if err != nil {
+				return nil, err
+			}
+			current = []*object{obj}
+			continue
+		}
+		if kind, ok := childStages[name]; ok {
+			current = s.children(current, kind)
+			continue
+		}
+
+		switch name {
+		case "alerts":
+			current = s.caseAlerts(current)
+		case "similarCases":
+			current = s.similar(current, KindCase, "case")
+		case "similarAlerts":
+			current = s.similar(current, KindAlert, "alert")
+		case "linkedCases":
+			current = s.linkedCases(current)
+		case "listCaseStatus":
+			current = staticObjects(s.CaseStatuses)
+		case "listAlertStatus":
+			current = staticObjects(s.AlertStatuses)
+		case "listObservableType":
+			current = staticObjects(defaultObservableTypes)
+		case "listVisibleUsers":
+			current = staticObjects([]map[string]interface{}{{"login": s.User, "name": "Test User"}})
+		case "filter":
+			var filtered []*object
+			for _, obj := range current {
+				if s.matches(obj, stage) {
+					filtered = append(filtered, obj)
+				}
+			}
+			current = filtered
+		case "sort":
+			s.sortObjects(current, stage["_fields"])
+		case "page":
+			from, to := toInt(stage["from"]), toInt(stage["to"])
+			if from > len(current) {
+				from = len(current)
+			}
+			if to > len(current) || to < from {
+				to = len(current)
+			}
+			current = current[from:to]
+		case "count":
+			return len(current), nil
+		case "aggregation":
+			return s.aggregate(current, stage)
+		default:
+			return nil, badRequest("query stage %q is not supported", name)
+		}
+	}
+
+	results := []map[string]interface{}{}
+	for _, obj := range current {
+		results = append(results, s.render(obj))
+	}
+	return results, nil
+}
+
+// caseAlerts returns the alerts imported into one of the cases
+func (s *Server) caseAlerts(cases []*object) []*object {
+	ids := map[interface{}]bool{}
+	for _, c := range cases {
+		ids[c.data["_id"]] = true
+	}
+
+	var alerts []*object
+	for _, alert := range s.list(KindAlert) {
+		if ids[alert.data["caseId"]] {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+// observableKey identifies observables with the same value
+type observableKey struct{ dataType, data interface{} }
+
+func keyOf(observable *object) observableKey {
+	return observableKey{observable.data["dataType"], observable.data["data"]}
+}
+
+// observableKeys returns the keys of all observables of obj
+func (s *Server) observableKeys(obj *object) map[observableKey]bool {
+	keys := map[observableKey]bool{}
+	for _, observable := range s.children([]*object{obj}, KindObservable) {
+		keys[keyOf(observable)] = true
+	}
+	return keys
+}
+
+// similar returns the objects of kind sharing observables with the current object
+// The results are wrapped like thehive5 does, e.g. {"case": {...}, "similarObservableCount": 1, ...}
+func (s *Server) similar(current []*object, kind string, key string) []*object {
+	if len(current) == 0 {
+		return nil
+	}
+	source := current[0]
+
+	shared := s.observableKeys(source)
+
+	var results []*object
+	for _, candidate := range s.list(kind) {
+		// the case an alert was imported into and its alerts are not similar but linked
+		if candidate.data["_id"] == source.data["caseId"] || candidate.data["caseId"] == source.data["_id"] {
+			continue
+		}
+
+		similarity := map[string]interface{}{}
+		types := map[string]int{}
+		count, iocCount, similarCount, similarIocCount := 0, 0, 0, 0
+		for _, observable := range s.children([]*object{candidate}, KindObservable) {
+			ioc := observable.data["ioc"] == true
+			count++
+			if ioc {
+				iocCount++
+			}
+			if shared[keyOf(observable)] {
+				similarCount++
+				types[fmt.Sprint(observable.data["dataType"])]++
+				if ioc {
+					similarIocCount++
+				}
+			}
+		}
+		if similarCount == 0 {
+			continue
+		}
+
+		similarity[key] = s.render(candidate)
+		similarity["observableCount"] = count
+		similarity["iocCount"] = iocCount
+		similarity["similarObservableCount"] = similarCount
+		similarity["similarIocCount"] = similarIocCount
+		similarity["observableTypes"] = types
+		results = append(results, &object{data: similarity})
+	}
+	return results
+}
+
+// linkedCases returns the other cases sharing observables with the current case
+// The shared observables are added as linkedWith and linksCount like thehive5 does
+func (s *Server) linkedCases(current []*object) []*object {
+	if len(current) == 0 {
+		return nil
+	}
+	source := current[0]
+
+	shared := s.observableKeys(source)
+
+	var results []*object
+	for _, candidate := range s.list(KindCase) {
+		if candidate == source {
+			continue
+		}
+
+		linkedWith := []interface{}{}
+		for _, observable := range s.children([]*object{candidate}, KindObservable) {
+			if shared[keyOf(observable)] {
+				linkedWith = append(linkedWith, s.render(observable))
+			}
+		}
+		if len(linkedWith) == 0 {
+			continue
+		}
+
+		linked := s.render(candidate)
+		linked["linkedWith"] = linkedWith
+		linked["linksCount"] = len(linkedWith)
+		results = append(results, &object{kind: KindCase, data: linked})
+	}
+	return results
+}
+
+// staticObjects wraps fixed data so the filter and sort stages can be applied
+func staticObjects(data []map[string]interface{}) []*object {
+	objects := make([]*object, len(data))
+	for i, d := range data {
+		objects[i] = &object{data: d}
+	}
+	return objects
+}
+
+// defaultCaseStatus are the case statuses of a fresh thehive5 installation
+var defaultCaseStatus = []map[string]interface{}{
+	{"value": "New", "stage": "New", "order": 1},
+	{"value": "InProgress", "stage": "InProgress", "order": 2},
+	{"value": "Indeterminate", "stage": "Closed", "order": 3},
+	{"value": "FalsePositive", "stage": "Closed", "order": 4},
+	{"value": "TruePositive", "stage": "Closed", "order": 5},
+	{"value": "Other", "stage": "Closed", "order": 6},
+	{"value": "Duplicated", "stage": "Closed", "order": 7},
+}
+
+// defaultAlertStatus are the alert statuses of a fresh thehive5 installation
+var defaultAlertStatus = []map[string]interface{}{
+	{"value": "New", "stage": "New", "order": 1},
+	{"value": "InProgress", "stage": "InProgress", "order": 2},
+	{"value": "Imported", "stage": "Imported", "order": 3},
+	{"value": "Pending", "stage": "InProgress", "order": 4},
+	{"value": "Ignored", "stage": "Closed", "order": 5},
+	{"value": "Duplicated", "stage": "Closed", "order": 6},
+	{"value": "FalsePositive", "stage": "Closed", "order": 7},
+	{"value": "TruePositive", "stage": "Closed", "order": 8},
+	{"value": "Other", "stage": "Closed", "order": 9},
+}
+
+// defaultObservableTypes are the observable types of a fresh thehive5 installation
+var defaultObservableTypes = []map[string]interface{}{
+	{"name": "autonomous-system", "isAttachment": false},
+	{"name": "domain", "isAttachment": false},
+	{"name": "file", "isAttachment": true},
+	{"name": "filename", "isAttachment": false},
+	{"name": "fqdn", "isAttachment": false},
+	{"name": "hash", "isAttachment": false},
+	{"name": "hostname", "isAttachment": false},
+	{"name": "ip", "isAttachment": false},
+	{"name": "mail", "isAttachment": false},
+	{"name": "mail-subject", "isAttachment": false},
+	{"name": "other", "isAttachment": false},
+	{"name": "regexp", "isAttachment": false},
+	{"name": "registry", "isAttachment": false},
+	{"name": "uri_path", "isAttachment": false},
+	{"name": "url", "isAttachment": false},
+	{"name": "user-agent", "isAttachment": false},
+}
+
+// matches evaluates all operators of a filter expression on obj
+func (s *Server) matches(obj *object, expr map[string]interface{}) bool {
+	// legacy leafs like {"_field": "keyword", "_value": "..."}
+	if field, ok := expr["_field"].(string); ok {
+		if _, ok := expr["_value"]; ok {
+			return s.compareField(obj, "_eq", field, expr["_value"])
+		}
+	}
+
+	for operator, operand := range expr {
+		if operator == "_name" {
+			continue
+		}
+		if !s.evaluate(obj, operator, operand) {
+			return false
+		}
+	}
+	return true
+}
+
+// evaluate applies a single operator
+func (s *Server) evaluate(obj *object, operator string, operand interface{}) bool {
+	switch operator {
+	case "_and":
+		for _, child := range toSlice(operand) {
+			if childExpr, ok := child.(map[string]interface{}); ok && !s.matches(obj, childExpr) {
+				return false
+			}
+		}
+		return true
+	case "_or":
+		for _, child := range toSlice(operand) {
+			if childExpr, ok := child.(map[string]interface{}); ok && s.matches(obj, childExpr) {
+				return true
+			}
+		}
+		return false
+	case "_not":
+		childExpr, _ := operand.(map[string]interface{})
+		return !s.matches(obj, childExpr)
+	case "_contains":
+		field, _ := operand.(string)
+		value, ok := getField(obj, field)
+		return ok && value != nil
+	}
+
+	args, _ := operand.(map[string]interface{})
+	field, _ := args["_field"].(string)
+
+	switch operator {
+	case "_in":
+		for _, value := range toSlice(args["_values"]) {
+			if s.compareField(obj, "_eq", field, value) {
+				return true
+			}
+		}
+		return false
+	case "_between":
+		return s.compareField(obj, "_gte", field, args["_from"]) && s.compareField(obj, "_lt", field, args["_to"])
+	}
+
+	return s.compareField(obj, operator, field, args["_value"])
+}
+
+// compareField compares a field of obj with value. Array fields match if any element matches
+func (s *Server) compareField(obj *object, operator string, field string, value interface{}) bool {
+	if field == "keyword" {
+		needle := strings.ToLower(fmt.Sprint(value))
+		for _, v := range obj.data {
+			if str, ok := v.(string); ok && strings.Contains(strings.ToLower(str), needle) {
+				return true
+			}
+		}
+		return false
+	}
+
+	fieldValue, ok := getField(obj, field)
+	if !ok {
+		return operator == "_ne"
+	}
+
+	if values, ok := fieldValue.([]interface{}); ok {
+		if operator == "_ne" {
+			return !containsValue(values, value)
+		}
+		for _, v := range values {
+			if compareValue(operator, v, value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return compareValue(operator, fieldValue, value)
+}
+
+// compareValue applies a comparison operator on two scalar values
+func compareValue(operator string, fieldValue interface{}, value interface{}) bool {
+	switch operator {
+	case "_like":
+		pattern := "(?i)^" + strings.ReplaceAll(regexp.QuoteMeta(fmt.Sprint(value)), `\*`, ".*") + "$"
+		matched, _ := regexp.MatchString(pattern, fmt.Sprint(fieldValue))
+		return matched
+	case "_startsWith":
+		return strings.HasPrefix(fmt.Sprint(fieldValue), fmt.Sprint(value))
+	case "_endsWith":
+		return strings.HasSuffix(fmt.Sprint(fieldValue), fmt.Sprint(value))
+	case "_match":
+		return strings.Contains(strings.ToLower(fmt.Sprint(fieldValue)), strings.ToLower(fmt.Sprint(value)))
+	}
+
+	cmp, ok := compare(fieldValue, value)
+	if !ok {
+		return operator == "_ne"
+	}
+
+	switch operator {
+	case "_eq":
+		return cmp == 0
+	case "_ne":
+		return cmp != 0
+	case "_lt":
+		return cmp < 0
+	case "_lte":
+		return cmp <= 0
+	case "_gt":
+		return cmp > 0
+	case "_gte":
+		return cmp >= 0
+	}
+	return false
+}
+
+// compare orders two values. Numbers sent as strings are compared as numbers
+func compare(a interface{}, b interface{}) (int, bool) {
+	if a == nil || b == nil {
+		if a == b {
+			return 0, true
+		}
+		return 0, false
+	}
+
+	fa, aNumber := toFloat(a)
+	fb, bNumber := toFloat(b)
+	if aNumber && bNumber {
+		switch {
+		case fa < fb:
+			return -1, true
+		case fa > fb:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	if ba, ok := a.(bool); ok {
+		bb, ok := b.(bool)
+		if !ok {
+			return 0, false
+		}
+		if ba == bb {
+			return 0, true
+		}
+		return 1, true
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b)), true
+}
+
+// getField reads a field of obj. customFields.<name> reads the value of a custom field
+func getField(obj *object, field string) (interface{}, bool) {
+	if name, ok := strings.CutPrefix(field, "customFields."); ok {
+		for _, cf := range toSlice(obj.data["customFields"]) {
+			if cfMap, ok := cf.(map[string]interface{}); ok && cfMap["name"] == name {
+				return cfMap["value"], true
+			}
+		}
+		if cfMap, ok := obj.data["customFields"].(map[string]interface{}); ok {
+			value, ok := cfMap[name]
+			return value, ok
+		}
+		return nil, false
+	}
+
+	value, ok := obj.data[field]
+	return value, ok
+}
+
+// sortObjects sorts by a list of {"field": "asc|desc"}
+func (s *Server) sortObjects(objects []*object, fields interface{}) {
+	sort.SliceStable(objects, func(i, j int) bool {
+		for _, rawField := range toSlice(fields) {
+			sortField, _ := rawField.(map[string]interface{})
+			for field, order := range sortField {
+				a, _ := getField(objects[i], field)
+				b, _ := getField(objects[j], field)
+				cmp, ok := compare(a, b)
+				if !ok || cmp == 0 {
+					continue
+				}
+				if order == "desc" {
+					return cmp > 0
+				}
+				return cmp < 0
+			}
+		}
+		return false
+	})
+}
+
+// toFloat converts numbers and numeric strings
+func toFloat(v interface{}) (float64, bool) {
+	switch value := v.(type) {
+	case float64:
+		return value, true
+	case int:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case string:
+		f, err := strconv.ParseFloat(value, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// toInt converts a json number
+func toInt(v interface{}) int {
+	f, _ := toFloat(v)
+	return int(f)
+}
+
+// toSlice returns v as slice. Single values are wrapped
+func toSlice(v interface{}) []interface{} {
+	switch value := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return value
+	case []string:
+		values := make([]interface{}, len(value))
+		for i, s := range value {
+			values[i] = s
+		}
+		return values
+	}
+	return []interface{}{v}
+}
+
+// containsValue reports if values contains value
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if cmp, ok := compare(v, value); ok && cmp == 0 {
+			return true
+		}
+	}
+	return false
+}
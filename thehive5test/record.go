@@ -0,0 +1,386 @@
+package thehive5test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	thehive5 "github.com/b401/goHive5"
+)
+
+// Redacted replaces credentials in recorded interactions
+const Redacted = "REDACTED"
+
+// redactedHeaders are never written into fixtures
+var redactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Thehive-Xsrf-Token"}
+
+// redactedFields are json attributes of request and response bodies which are never written into fixtures
+// Only credentials are listed, generic names like key are used by regular data, e.g. aggregation buckets
+var redactedFields = []string{"password", "apiKey", "token"}
+
+// An Interaction is a recorded request and the response of thehive5
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// A RecordedRequest is the part of a request used to find the matching response
+// Json bodies are stored as Body, everything else (e.g. multipart) as RawBody
+type RecordedRequest struct {
+	Method  string          `json:"method"`
+	Path    string          `json:"path"`
+	Query   string          `json:"query,omitempty"`
+	Header  http.Header     `json:"header,omitempty"`
+	Body    json.RawMessage `json:"body,omitempty"`
+	RawBody string          `json:"rawBody,omitempty"`
+}
+
+// A RecordedResponse is replayed as it was received
+type RecordedResponse struct {
+	StatusCode int             `json:"statusCode"`
+	Header     http.Header     `json:"header,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	RawBody    string          `json:"rawBody,omitempty"`
+}
+
+// A Recorder is a thehive5.HttpClient which captures all requests and responses of the wrapped client
+// Credentials are redacted in headers and in json bodies at any depth, call Save to write the golden file
+//
+//	hive := thehive5.CreateLogin(url, apikey, true)
+//	rec := thehive5test.NewRecorder(hive.Client, "testdata/get_case.json")
+//	rec.RedactHeaders = []string{"X-Api-Key"}
+//	hive.Client = rec
+//	defer rec.Save()
+type Recorder struct {
+	Client thehive5.HttpClient
+	Path   string
+
+	// RedactHeaders and RedactFields are redacted in addition to the default credentials,
+	// e.g. a custom header added with thehive5.WithHeader
+	RedactHeaders []string
+	RedactFields  []string
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// NewRecorder wraps client and records into the file path
+func NewRecorder(client thehive5.HttpClient, path string) *Recorder {
+	return &Recorder{Client: client, Path: path}
+}
+
+// Do sends the request with the wrapped client and records the interaction
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  req.URL.RawQuery,
+			Header: redactHeader(req.Header, r.RedactHeaders),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     redactHeader(resp.Header, r.RedactHeaders),
+		},
+	}
+	interaction.Request.Body, interaction.Request.RawBody = splitBody(redactBody(reqBody, r.RedactFields))
+	interaction.Response.Body, interaction.Response.RawBody = splitBody(redactBody(respBody, r.RedactFields))
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, interaction)
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// Interactions returns the interactions recorded so far
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Interaction{}, r.interactions...)
+}
+
+// Save writes the recorded interactions into the golden file
+func (r *Recorder) Save() error {
+	data, err := json.MarshalIndent(r.Interactions(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.Path, append(data, '\n'), 0o644)
+}
+
+// A Replayer is a thehive5.HttpClient which serves the responses of a golden file
+// Requests are matched by method, path, query and body, redacted values match anything. Identical
+// requests are answered in the recorded order, the last matching response is repeated once all were used
+//
+//	rep, err := thehive5test.NewReplayer("testdata/get_case.json")
+//	hive, err := thehive5.NewClient("http://thehive.invalid", thehive5.WithHTTPClient(rep))
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer loads a golden file written by a Recorder
+func NewReplayer(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var interactions []Interaction
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+	}
+
+	return NewReplayerFromInteractions(interactions), nil
+}
+
+// NewReplayerFromInteractions replays interactions, e.g. from Recorder.Interactions
+func NewReplayerFromInteractions(interactions []Interaction) *Replayer {
+	return &Replayer{interactions: interactions, used: make([]bool, len(interactions))}
+}
+
+// Do answers the request with the matching recorded response
+func (r *Replayer) Do(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	match := -1
+	for i, interaction := range r.interactions {
+		if !requestMatches(interaction.Request, req, reqBody) {
+			continue
+		}
+		match = i
+		if !r.used[i] {
+			break
+		}
+	}
+	if match == -1 {
+		return nil, fmt.Errorf("thehive5test: no recorded interaction for %s %s", req.Method, req.URL.RequestURI())
+	}
+	r.used[match] = true
+
+	recorded := r.interactions[match].Response
+	body := []byte(recorded.Body)
+	if len(recorded.RawBody) != 0 {
+		body = []byte(recorded.RawBody)
+	}
+
+	header := recorded.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Unused returns the recorded interactions which were never replayed
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unused []Interaction
+	for i, interaction := range r.interactions {
+		if !r.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
+
+// requestMatches compares a recorded request with an incoming one
+// Multipart bodies are not compared as their boundary is random
+func requestMatches(recorded RecordedRequest, req *http.Request, body []byte) bool {
+	if recorded.Method != req.Method || recorded.Path != req.URL.Path || recorded.Query != req.URL.RawQuery {
+		return false
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		return true
+	}
+
+	jsonBody, rawBody := splitBody(body)
+	if len(recorded.RawBody) != 0 || len(rawBody) != 0 {
+		return recorded.RawBody == rawBody
+	}
+	if len(recorded.Body) == 0 || len(jsonBody) == 0 {
+		return len(recorded.Body) == len(jsonBody)
+	}
+
+	var a, b interface{}
+	json.Unmarshal(recorded.Body, &a)
+	json.Unmarshal(jsonBody, &b)
+	return valueMatches(a, b)
+}
+
+// valueMatches compares a recorded json value with an incoming one, a redacted value matches anything
+func valueMatches(recorded interface{}, value interface{}) bool {
+	switch recorded := recorded.(type) {
+	case string:
+		if recorded == Redacted {
+			return true
+		}
+	case map[string]interface{}:
+		object, ok := value.(map[string]interface{})
+		if !ok || len(object) != len(recorded) {
+			return false
+		}
+		for key, recordedValue := range recorded {
+			objectValue, ok := object[key]
+			if !ok || !valueMatches(recordedValue, objectValue) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		list, ok := value.([]interface{})
+		if !ok || len(list) != len(recorded) {
+			return false
+		}
+		for i := range recorded {
+			if !valueMatches(recorded[i], list[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(recorded, value)
+}
+
+// redactHeader returns a copy of header without credentials and the extra header names
+func redactHeader(header http.Header, extra []string) http.Header {
+	redacted := header.Clone()
+	for _, name := range append(append([]string{}, redactedHeaders...), extra...) {
+		if len(redacted.Values(name)) != 0 {
+			redacted.Set(name, Redacted)
+		}
+	}
+	return redacted
+}
+
+// redactBody removes credentials and the extra fields from a json body at any depth
+// Other bodies are returned unchanged
+func redactBody(body []byte, extra []string) []byte {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return body
+	}
+
+	fields := append(append([]string{}, redactedFields...), extra...)
+	if !redactValue(value, fields) {
+		return body
+	}
+
+	redacted, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// redactValue replaces the fields in all objects within value and reports if anything was replaced
+func redactValue(value interface{}, fields []string) bool {
+	changed := false
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if containsFold(fields, key) {
+				value[key] = Redacted
+				changed = true
+				continue
+			}
+			if redactValue(child, fields) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, child := range value {
+			if redactValue(child, fields) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// containsFold reports if name is in names, ignoring case
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitBody returns valid json as raw message and everything else as string
+func splitBody(body []byte) (json.RawMessage, string) {
+	if len(body) == 0 {
+		return nil, ""
+	}
+	if json.Valid(body) {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, body); err == nil {
+			return compact.Bytes(), ""
+		}
+	}
+	return nil, string(body)
+}
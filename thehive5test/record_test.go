@@ -0,0 +1,151 @@
+package thehive5test_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	thehive5 "github.com/b401/goHive5"
+	"github.com/b401/goHive5/thehive5test"
+)
+
+// session runs the same requests against the recording and the replaying client
+func session(t *testing.T, hive *thehive5.Hivedata, file *os.File) (*thehive5.HiveCaseResponse, []thehive5.ObservableResponse) {
+	t.Helper()
+
+	created, err := hive.CreateCase(&thehive5.HiveCase{Title: "Phishing", Description: "reported mail", Assignee: "analyst@corp.local"})
+	if err != nil {
+		t.Fatalf("CreateCase: %v", err)
+	}
+
+	if _, err := file.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := hive.AddCaseObservableFile(created.Number, &thehive5.Observable{DataType: "file", Message: "attachment of the mail"}, file); err != nil {
+		t.Fatalf("AddCaseObservableFile: %v", err)
+	}
+
+	got, err := hive.GetCase(created.Number)
+	if err != nil {
+		t.Fatalf("GetCase: %v", err)
+	}
+	observables, err := hive.GetCaseObservables(created.Number)
+	if err != nil {
+		t.Fatalf("GetCaseObservables: %v", err)
+	}
+	return got, observables
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+	dir := t.TempDir()
+	fixture := filepath.Join(dir, "testdata", "phishing.json")
+
+	file, err := os.Create(filepath.Join(dir, "mail.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString("Subject: invoice\n\nplease pay"); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := thehive5test.NewRecorder(srv.Client(), fixture)
+	rec.RedactHeaders = []string{"X-Api-Key"}
+	rec.RedactFields = []string{"assignee"}
+	recording := srv.Hive(thehive5.WithHTTPClient(rec), thehive5.WithHeader("X-Api-Key", "custom-secret"))
+
+	recordedCase, recordedObservables := session(t, recording, file)
+	if recordedCase.Assignee != "analyst@corp.local" {
+		t.Fatalf("the recorded client got assignee %q, redaction must only affect the fixture", recordedCase.Assignee)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{srv.APIKey, "custom-secret", "analyst@corp.local"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("fixture contains %q", secret)
+		}
+	}
+	if !strings.Contains(string(data), "multipart/form-data") {
+		t.Fatal("fixture doesn't contain the upload")
+	}
+
+	rep, err := thehive5test.NewReplayer(fixture)
+	if err != nil {
+		t.Fatalf("NewReplayer: %v", err)
+	}
+	replaying, err := thehive5.NewClient("http://thehive.invalid", thehive5.WithAPIKey("other-key"), thehive5.WithHTTPClient(rep))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replayedCase, replayedObservables := session(t, replaying, file)
+	if unused := rep.Unused(); len(unused) != 0 {
+		t.Fatalf("%d interactions weren't replayed", len(unused))
+	}
+
+	if replayedCase.Number != recordedCase.Number || replayedCase.Title != recordedCase.Title || replayedCase.Assignee != thehive5test.Redacted {
+		t.Fatalf("replayed case %+v, recorded %+v", replayedCase, recordedCase)
+	}
+	if len(replayedObservables) != 1 || len(recordedObservables) != 1 {
+		t.Fatalf("replayed %d observables, recorded %d", len(replayedObservables), len(recordedObservables))
+	}
+	if attachment := replayedObservables[0].Attachment; attachment != recordedObservables[0].Attachment || attachment.Name != "mail.eml" {
+		t.Fatalf("replayed attachment %+v, recorded %+v", attachment, recordedObservables[0].Attachment)
+	}
+
+	// a request which was never recorded fails instead of reaching the network
+	if _, err := replaying.GetCase(recordedCase.Number + 1); err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Fatalf("GetCase of an unrecorded case = %v", err)
+	}
+}
+
+func TestRecorderRedactsOnlyCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"key":"severity","count":3,"user":{"login":"analyst","apikey":"user-secret","token":"token-secret"}}`))
+	}))
+	defer srv.Close()
+	fixture := filepath.Join(t.TempDir(), "user.json")
+
+	rec := thehive5test.NewRecorder(srv.Client(), fixture)
+	rec.RedactFields = []string{"login"}
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/v1/user", strings.NewReader(`{"login":"analyst","password":"user-password","key":"value"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := rec.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"user-secret", "token-secret", "user-password", "analyst"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("fixture contains %q", secret)
+		}
+	}
+	// key isn't a credential, it is kept in both bodies
+	for _, kept := range []string{`"key":"severity"`, `"key":"value"`} {
+		if !strings.Contains(strings.ReplaceAll(string(data), " ", ""), kept) {
+			t.Fatalf("fixture doesn't contain %s:\n%s", kept, data)
+		}
+	}
+}
@@ -0,0 +1,890 @@
+/*
+thehive5test provides an in-memory fake of thehive5 for end-to-end tests of code using the thehive5 client.
+
+	srv := thehive5test.NewServer()
+	defer srv.Close()
+
+	hive := srv.Hive()
+	created, err := hive.CreateCase(&thehive5.HiveCase{Title: "test", Description: "test"})
+*/
+package thehive5test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	thehive5 "github.com/b401/goHive5"
+)
+
+// Kinds of objects stored by the fake server. They match the _type attribute of thehive5
+const (
+	KindCase        = "Case"
+	KindAlert       = "Alert"
+	KindObservable  = "Observable"
+	KindTask        = "Task"
+	KindLog         = "Log"
+	KindComment     = "Comment"
+	KindCustomEvent = "CustomEvent"
+	KindCustomField = "CustomField"
+)
+
+// object is a stored document together with the object it belongs to
+type object struct {
+	kind   string
+	parent string
+	data   map[string]interface{}
+}
+
+// A Server is an in-memory fake of the thehive5 api
+// It embeds the underlying httptest.Server, use Close to shut it down
+type Server struct {
+	*httptest.Server
+	// APIKey is the only apikey accepted by the server. Empty disables the authentication
+	APIKey string
+	// User is reported as creator of all objects
+	User string
+	// Password of User for basic auth and /api/v1/login
+	Password string
+	// CaseStatuses and AlertStatuses are returned by listCaseStatus and listAlertStatus and define the stage of a status
+	// They default to the statuses of a fresh thehive5 installation, e.g. {"value": "New", "stage": "New", "order": 1}
+	CaseStatuses  []map[string]interface{}
+	AlertStatuses []map[string]interface{}
+	// Intercept is called with every request before it is handled, e.g. to inject failures or to change
+	// objects between two requests of a client. A non nil error is sent instead, use Error to choose the status
+	Intercept func(req Request) error
+
+	mu       sync.Mutex
+	objects  map[string]*object
+	order    []string
+	lastId   int
+	lastCase int
+	requests []Request
+
+	sessions    map[string]string
+	lastSession int
+}
+
+// A Request is a request received by the fake server
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	// Body is the json body or the _json field of a multipart body
+	Body map[string]interface{}
+}
+
+// httpError is returned by the handlers and rendered like a thehive5 error
+type httpError struct {
+	status  int
+	errType string
+	message string
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("%s: %s", e.errType, e.message)
+}
+
+func notFound(kind string, id string) error {
+	return &httpError{http.StatusNotFound, "NotFoundError", fmt.Sprintf("%s %s not found", kind, id)}
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &httpError{http.StatusBadRequest, "BadRequestError", fmt.Sprintf(format, args...)}
+}
+
+// Error returns an error which Intercept can use to answer a request like thehive5
+func Error(status int, errType string, message string) error {
+	return &httpError{status, errType, message}
+}
+
+// NewServer starts a new fake thehive5 server
+func NewServer() *Server {
+	s := &Server{
+		APIKey:        "thehive5test",
+		User:          "test@thehive.local",
+		Password:      "thehive5test",
+		CaseStatuses:  append([]map[string]interface{}{}, defaultCaseStatus...),
+		AlertStatuses: append([]map[string]interface{}{}, defaultAlertStatus...),
+		objects:       map[string]*object{},
+		sessions:      map[string]string{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Hive returns a thehive5 client configured for the fake server
+// The options are applied after the apikey and the http client of the server
+func (s *Server) Hive(opts ...thehive5.Option) *thehive5.Hivedata {
+	opts = append([]thehive5.Option{
+		thehive5.WithAPIKey(s.APIKey),
+		thehive5.WithHTTPClient(s.Server.Client()),
+	}, opts...)
+
+	// the options above can't fail
+	hive, _ := thehive5.NewClient(s.URL, opts...)
+	return hive
+}
+
+// Reset removes all stored objects and received requests
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.objects = map[string]*object{}
+	s.order = nil
+	s.lastCase = 0
+	s.requests = nil
+}
+
+// Requests returns the requests received since the server was started or the requests were cleared
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request{}, s.requests...)
+}
+
+// ClearRequests forgets the received requests
+func (s *Server) ClearRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = nil
+}
+
+// Objects returns a copy of all stored objects of a kind in creation order
+func (s *Server) Objects(kind string) []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var objects []map[string]interface{}
+	for _, obj := range s.list(kind) {
+		objects = append(objects, s.render(obj))
+	}
+	return objects
+}
+
+// handle records, authenticates and dispatches a request
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, attachment, err := readBody(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	req := Request{Method: r.Method, Path: r.URL.Path, Header: r.Header.Clone(), Body: copyBody(body)}
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+
+	// called without the lock so the interceptor can send requests to the server itself
+	if s.Intercept != nil {
+		if err := s.Intercept(req); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v1/login":
+		s.login(w, body)
+		return
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v1/logout":
+		s.logout(w, r)
+		return
+	}
+
+	if len(s.APIKey) != 0 && !s.authenticated(r) {
+		writeError(w, &httpError{http.StatusUnauthorized, "AuthenticationError", "Authentication failure"})
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, "/api/v1/") {
+		writeError(w, notFound("Route", r.URL.Path))
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/"), "/"), "/")
+
+	s.mu.Lock()
+	resp, err := s.route(r.Method, parts, body, attachment)
+	s.mu.Unlock()
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// route maps the path of a request to the handler functions
+func (s *Server) route(m string, parts []string, body map[string]interface{}, attachment map[string]interface{}) (interface{}, error) {
+	if len(parts) == 1 && parts[0] == "query" && m == http.MethodPost {
+		return s.query(body)
+	}
+
+	if len(parts) == 1 && m == http.MethodPost {
+		switch parts[0] {
+		case "case":
+			return s.render(s.createCase(body)), nil
+		case "alert":
+			alert, err := s.createAlert(body)
+			if err != nil {
+				return nil, err
+			}
+			return s.render(alert), nil
+		case "customField":
+			field, err := s.createCustomField(body)
+			if err != nil {
+				return nil, err
+			}
+			return s.render(field), nil
+		}
+	}
+
+	if len(parts) == 1 && parts[0] == "customField" && m == http.MethodGet {
+		var fields []interface{}
+		for _, obj := range s.list(KindCustomField) {
+			fields = append(fields, s.render(obj))
+		}
+		return fields, nil
+	}
+
+	if parts[len(parts)-1] == "_bulk" {
+		return s.bulk(m, parts, body)
+	}
+
+	if len(parts) < 2 {
+		return nil, notFound("Route", strings.Join(parts, "/"))
+	}
+
+	if m == http.MethodPost && len(parts) == 3 && parts[0] == "case" && parts[1] == "_merge" {
+		merged, err := s.mergeCases(strings.Split(parts[2], ","))
+		if err != nil {
+			return nil, err
+		}
+		return s.render(merged), nil
+	}
+
+	kind, ok := map[string]string{
+		"case":        KindCase,
+		"alert":       KindAlert,
+		"observable":  KindObservable,
+		"task":        KindTask,
+		"customEvent": KindCustomEvent,
+		"customField": KindCustomField,
+	}[parts[0]]
+	if !ok {
+		return nil, notFound("Route", strings.Join(parts, "/"))
+	}
+
+	obj, err := s.lookup(kind, parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(parts) == 2 {
+		switch m {
+		case http.MethodGet:
+			return s.render(obj), nil
+		case http.MethodPatch:
+			s.patch(obj, body)
+			return nil, nil
+		case http.MethodDelete:
+			s.delete(obj)
+			return nil, nil
+		}
+		return nil, badRequest("method %s not supported", m)
+	}
+
+	switch {
+	case m == http.MethodPost && parts[2] == "observable" && (kind == KindCase || kind == KindAlert):
+		if attachment != nil {
+			body["attachment"] = attachment
+			if _, ok := body["dataType"]; !ok {
+				body["dataType"] = "file"
+			}
+		}
+		observable := s.insert(KindObservable, obj, body, map[string]interface{}{"tlp": 2, "pap": 2, "ioc": false, "sighted": false, "tags": []interface{}{}})
+		return []interface{}{s.render(observable)}, nil
+	case m == http.MethodPost && parts[2] == "task" && kind == KindCase:
+		return s.render(s.insert(KindTask, obj, body, map[string]interface{}{"status": "Waiting", "flag": false, "mandatory": false})), nil
+	case m == http.MethodPost && parts[2] == "log" && kind == KindTask:
+		now := s.now()
+		return s.render(s.insert(KindLog, obj, body, map[string]interface{}{"date": now, "owner": s.User})), nil
+	case m == http.MethodPost && parts[2] == "comment" && (kind == KindCase || kind == KindAlert):
+		now := s.now()
+		return s.render(s.insert(KindComment, obj, body, map[string]interface{}{"createdAt": now, "createdBy": s.User, "isEdited": false})), nil
+	case m == http.MethodPost && parts[2] == "customEvent" && kind == KindCase:
+		return s.render(s.insert(KindCustomEvent, obj, body, map[string]interface{}{"date": s.now()})), nil
+	case m == http.MethodPost && kind == KindAlert && alertActions[parts[2]] != nil:
+		s.patch(obj, alertActions[parts[2]])
+		return s.render(obj), nil
+	case m == http.MethodGet && parts[2] == "timeline" && kind == KindCase:
+		return s.timeline(obj), nil
+	case m == http.MethodPost && parts[2] == "case" && kind == KindAlert:
+		created, err := s.importAlert(obj, body)
+		if err != nil {
+			return nil, err
+		}
+		return s.render(created), nil
+	case m == http.MethodPost && parts[2] == "merge" && len(parts) == 4 && kind == KindAlert:
+		target, err := s.lookup(KindCase, parts[3])
+		if err != nil {
+			return nil, err
+		}
+		if err := s.mergeAlert(obj, target); err != nil {
+			return nil, err
+		}
+		return s.render(target), nil
+	}
+
+	return nil, notFound("Route", strings.Join(parts, "/"))
+}
+
+// alertActions map the action endpoints of alerts to the attributes they change
+var alertActions = map[string]map[string]interface{}{
+	"follow":       {"follow": true},
+	"unfollow":     {"follow": false},
+	"markAsRead":   {"read": true},
+	"markAsUnread": {"read": false},
+}
+
+// now returns the current time in thehive5 format
+func (s *Server) now() int64 {
+	return time.Now().UnixMilli()
+}
+
+// insert stores a new object and fills in the attributes set by thehive5
+func (s *Server) insert(kind string, parent *object, data map[string]interface{}, defaults map[string]interface{}) *object {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	for key, value := range defaults {
+		if _, ok := data[key]; !ok {
+			data[key] = value
+		}
+	}
+
+	s.lastId++
+	id := fmt.Sprintf("~%d", 4096+s.lastId)
+	data["_id"] = id
+	data["_type"] = kind
+	data["_createdAt"] = s.now()
+	data["_createdBy"] = s.User
+
+	obj := &object{kind: kind, data: data}
+	if parent != nil {
+		obj.parent = parent.data["_id"].(string)
+	}
+
+	s.objects[id] = obj
+	s.order = append(s.order, id)
+	return obj
+}
+
+// lookup finds an object by id. Cases can be found by their number as well
+func (s *Server) lookup(kind string, idOrName string) (*object, error) {
+	if obj, ok := s.objects[idOrName]; ok && obj.kind == kind {
+		return obj, nil
+	}
+
+	if kind == KindCase {
+		if number, err := strconv.Atoi(idOrName); err == nil {
+			for _, obj := range s.list(KindCase) {
+				if toInt(obj.data["number"]) == number {
+					return obj, nil
+				}
+			}
+		}
+	}
+
+	if kind == KindCustomField {
+		for _, obj := range s.list(KindCustomField) {
+			if obj.data["name"] == idOrName {
+				return obj, nil
+			}
+		}
+	}
+
+	return nil, notFound(kind, idOrName)
+}
+
+// list returns all objects of a kind in creation order
+func (s *Server) list(kind string) []*object {
+	var objects []*object
+	for _, id := range s.order {
+		if obj, ok := s.objects[id]; ok && obj.kind == kind {
+			objects = append(objects, obj)
+		}
+	}
+	return objects
+}
+
+// children returns all objects of a kind belonging to one of the parents
+func (s *Server) children(parents []*object, kind string) []*object {
+	ids := map[string]bool{}
+	for _, parent := range parents {
+		ids[parent.data["_id"].(string)] = true
+	}
+
+	var objects []*object
+	for _, obj := range s.list(kind) {
+		if ids[obj.parent] {
+			objects = append(objects, obj)
+		}
+	}
+	return objects
+}
+
+// render returns a copy of an object as thehive5 would return it
+func (s *Server) render(obj *object) map[string]interface{} {
+	data := map[string]interface{}{}
+	for key, value := range obj.data {
+		data[key] = value
+	}
+
+	if obj.kind == KindAlert {
+		data["observableCount"] = len(s.children([]*object{obj}, KindObservable))
+	}
+	if _, ok := data["extraData"]; !ok {
+		data["extraData"] = map[string]interface{}{}
+	}
+	return data
+}
+
+// createCase stores a case and the tasks defined in it
+func (s *Server) createCase(data map[string]interface{}) *object {
+	tasks, _ := data["tasks"].([]interface{})
+	delete(data, "tasks")
+
+	s.lastCase++
+	created := s.insert(KindCase, nil, data, map[string]interface{}{
+		"status":       "New",
+		"severity":     2,
+		"tlp":          2,
+		"pap":          2,
+		"flag":         false,
+		"tags":         []interface{}{},
+		"customFields": []interface{}{},
+		"startDate":    s.now(),
+	})
+	created.data["number"] = s.lastCase
+	created.data["stage"] = s.stageOf(KindCase, created.data["status"])
+
+	for _, task := range tasks {
+		if taskData, ok := task.(map[string]interface{}); ok {
+			s.insert(KindTask, created, taskData, map[string]interface{}{"status": "Waiting", "flag": false, "mandatory": false})
+		}
+	}
+
+	return created
+}
+
+// createCustomField declares a custom field, the name has to be unique
+func (s *Server) createCustomField(data map[string]interface{}) (*object, error) {
+	name, _ := data["name"].(string)
+	if len(name) == 0 {
+		return nil, badRequest("custom field name is required")
+	}
+	if _, err := s.lookup(KindCustomField, name); err == nil {
+		return nil, &httpError{http.StatusBadRequest, "CreateError", fmt.Sprintf("CustomField %s already exists", name)}
+	}
+
+	return s.insert(KindCustomField, nil, data, map[string]interface{}{
+		"displayName": name,
+		"group":       "default",
+		"description": "",
+		"mandatory":   false,
+	}), nil
+}
+
+// createAlert stores an alert and its observables
+// Like thehive5 it refuses alerts with an already existing type, source and sourceRef
+func (s *Server) createAlert(data map[string]interface{}) (*object, error) {
+	for _, existing := range s.list(KindAlert) {
+		if existing.data["type"] == data["type"] && existing.data["source"] == data["source"] && existing.data["sourceRef"] == data["sourceRef"] {
+			return nil, &httpError{http.StatusBadRequest, "CreateError", fmt.Sprintf("Alert %v/%v/%v already exists", data["type"], data["source"], data["sourceRef"])}
+		}
+	}
+
+	observables, _ := data["observables"].([]interface{})
+	delete(data, "observables")
+
+	created := s.insert(KindAlert, nil, data, map[string]interface{}{
+		"status":       "New",
+		"severity":     2,
+		"tlp":          2,
+		"pap":          2,
+		"follow":       true,
+		"imported":     false,
+		"read":         false,
+		"tags":         []interface{}{},
+		"customFields": []interface{}{},
+		"date":         s.now(),
+	})
+	created.data["stage"] = s.stageOf(KindAlert, created.data["status"])
+
+	for _, observable := range observables {
+		if observableData, ok := observable.(map[string]interface{}); ok {
+			s.insert(KindObservable, created, observableData, map[string]interface{}{"tlp": 2, "pap": 2, "ioc": false, "sighted": false, "tags": []interface{}{}})
+		}
+	}
+
+	return created, nil
+}
+
+// importAlert creates a new case from an alert
+func (s *Server) importAlert(alert *object, data map[string]interface{}) (*object, error) {
+	if _, ok := alert.data["caseId"]; ok {
+		return nil, badRequest("alert %s has already been imported", alert.data["_id"])
+	}
+
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	for _, key := range []string{"title", "description", "severity", "tlp", "pap", "tags", "customFields", "summary", "assignee"} {
+		if _, ok := data[key]; !ok && alert.data[key] != nil {
+			data[key] = alert.data[key]
+		}
+	}
+
+	created := s.createCase(data)
+	return created, s.mergeAlert(alert, created)
+}
+
+// mergeAlert copies the observables of an alert into a case and marks the alert as imported
+func (s *Server) mergeAlert(alert *object, target *object) error {
+	if _, ok := alert.data["caseId"]; ok {
+		return badRequest("alert %s has already been imported", alert.data["_id"])
+	}
+
+	for _, observable := range s.children([]*object{alert}, KindObservable) {
+		copied := map[string]interface{}{}
+		for key, value := range observable.data {
+			if !strings.HasPrefix(key, "_") {
+				copied[key] = value
+			}
+		}
+		s.insert(KindObservable, target, copied, nil)
+	}
+
+	alert.data["caseId"] = target.data["_id"]
+	alert.data["imported"] = true
+	alert.data["status"] = "Imported"
+	alert.data["stage"] = "Imported"
+	alert.data["importedDate"] = s.now()
+	alert.data["_updatedAt"] = s.now()
+	alert.data["_updatedBy"] = s.User
+	return nil
+}
+
+// mergeCases creates a new case from the cases, moves everything into it and deletes the merged cases
+func (s *Server) mergeCases(ids []string) (*object, error) {
+	if len(ids) < 2 {
+		return nil, badRequest("at least two cases are required for a merge")
+	}
+
+	var cases []*object
+	for _, id := range ids {
+		c, err := s.lookup(KindCase, id)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, c)
+	}
+
+	var titles, descriptions []string
+	var tags []interface{}
+	data := map[string]interface{}{"severity": 0, "tlp": 0, "pap": 0}
+	for _, c := range cases {
+		titles = append(titles, fmt.Sprint(c.data["title"]))
+		descriptions = append(descriptions, fmt.Sprint(c.data["description"]))
+		for _, tag := range toSlice(c.data["tags"]) {
+			if !containsValue(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		for _, key := range []string{"severity", "tlp", "pap"} {
+			if toInt(c.data[key]) > toInt(data[key]) {
+				data[key] = toInt(c.data[key])
+			}
+		}
+	}
+	data["title"] = strings.Join(titles, " / ")
+	data["description"] = strings.Join(descriptions, "\n\n")
+	data["tags"] = tags
+	data["customFields"] = cases[0].data["customFields"]
+
+	merged := s.createCase(data)
+	mergedId := merged.data["_id"]
+	for _, c := range cases {
+		id := c.data["_id"].(string)
+		for _, obj := range s.objects {
+			if obj.parent == id {
+				obj.parent = mergedId.(string)
+			}
+			if obj.kind == KindAlert && obj.data["caseId"] == id {
+				obj.data["caseId"] = mergedId
+			}
+		}
+		delete(s.objects, id)
+	}
+
+	return merged, nil
+}
+
+// bulk handles the bulk endpoints. Like thehive5 nothing is changed if one of the objects doesn't exist
+func (s *Server) bulk(m string, parts []string, body map[string]interface{}) (interface{}, error) {
+	route := m + " " + strings.Join(parts, "/")
+
+	var kind string
+	var idsField string
+	switch route {
+	case "PATCH alert/_bulk", "POST alert/delete/_bulk":
+		kind, idsField = KindAlert, "ids"
+	case "POST alert/merge/_bulk":
+		kind, idsField = KindAlert, "alertIds"
+	case "PATCH case/_bulk":
+		kind, idsField = KindCase, "ids"
+	default:
+		return nil, notFound("Route", strings.Join(parts, "/"))
+	}
+
+	var objects []*object
+	for _, id := range toSlice(body[idsField]) {
+		obj, err := s.lookup(kind, fmt.Sprint(id))
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+	delete(body, idsField)
+
+	switch route {
+	case "POST alert/merge/_bulk":
+		target, err := s.lookup(KindCase, fmt.Sprint(body["caseId"]))
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objects {
+			if _, ok := obj.data["caseId"]; ok {
+				return nil, badRequest("alert %s has already been imported", obj.data["_id"])
+			}
+		}
+		for _, obj := range objects {
+			s.mergeAlert(obj, target)
+		}
+		return s.render(target), nil
+	case "POST alert/delete/_bulk":
+		for _, obj := range objects {
+			s.delete(obj)
+		}
+	default:
+		for _, obj := range objects {
+			s.patch(obj, body)
+		}
+	}
+	return nil, nil
+}
+
+// patch updates the attributes of an object
+func (s *Server) patch(obj *object, data map[string]interface{}) {
+	for key, value := range data {
+		switch key {
+		case "addTags":
+			tags, _ := obj.data["tags"].([]interface{})
+			for _, tag := range toSlice(value) {
+				if !containsValue(tags, tag) {
+					tags = append(tags, tag)
+				}
+			}
+			obj.data["tags"] = tags
+		case "removeTags":
+			remove := toSlice(value)
+			var tags []interface{}
+			for _, tag := range toSlice(obj.data["tags"]) {
+				if !containsValue(remove, tag) {
+					tags = append(tags, tag)
+				}
+			}
+			obj.data["tags"] = tags
+		default:
+			if name, ok := strings.CutPrefix(key, "customFields."); ok {
+				s.setCustomField(obj, name, value)
+				continue
+			}
+			obj.data[key] = value
+		}
+	}
+
+	if _, ok := data["status"]; ok && (obj.kind == KindCase || obj.kind == KindAlert) {
+		obj.data["stage"] = s.stageOf(obj.kind, obj.data["status"])
+		if obj.kind == KindCase && obj.data["stage"] == "Closed" {
+			obj.data["endDate"] = s.now()
+		}
+	}
+
+	obj.data["_updatedAt"] = s.now()
+	obj.data["_updatedBy"] = s.User
+}
+
+// setCustomField sets a single custom field of an object like a patch of customFields.<name>
+// The type is taken from the definition, the existing field or the value
+func (s *Server) setCustomField(obj *object, name string, value interface{}) {
+	fields := toSlice(obj.data["customFields"])
+	for _, rawField := range fields {
+		if field, ok := rawField.(map[string]interface{}); ok && field["name"] == name {
+			field["value"] = value
+			return
+		}
+	}
+
+	fieldType := ""
+	if definition, err := s.lookup(KindCustomField, name); err == nil {
+		fieldType, _ = definition.data["type"].(string)
+	}
+	if len(fieldType) == 0 {
+		switch v := value.(type) {
+		case bool:
+			fieldType = "boolean"
+		case float64:
+			fieldType = "float"
+			if v == float64(int64(v)) {
+				fieldType = "integer"
+			}
+		default:
+			fieldType = "string"
+		}
+	}
+
+	obj.data["customFields"] = append(fields, map[string]interface{}{"name": name, "type": fieldType, "value": value, "order": len(fields)})
+}
+
+// delete removes an object and everything that belongs to it
+func (s *Server) delete(obj *object) {
+	id := obj.data["_id"].(string)
+	for _, child := range s.objects {
+		if child.parent == id {
+			s.delete(child)
+		}
+	}
+	delete(s.objects, id)
+}
+
+// timeline returns the custom events and tasks of a case as timeline events
+func (s *Server) timeline(caseObj *object) map[string]interface{} {
+	events := []interface{}{}
+	for _, event := range s.children([]*object{caseObj}, KindCustomEvent) {
+		events = append(events, map[string]interface{}{
+			"date":     event.data["date"],
+			"kind":     "CustomEvent",
+			"entity":   KindCustomEvent,
+			"entityId": event.data["_id"],
+			"details":  map[string]interface{}{"customEvent": s.render(event)},
+		})
+	}
+	for _, task := range s.children([]*object{caseObj}, KindTask) {
+		events = append(events, map[string]interface{}{
+			"date":     task.data["_createdAt"],
+			"kind":     "TaskCreated",
+			"entity":   KindTask,
+			"entityId": task.data["_id"],
+			"details":  map[string]interface{}{"task": s.render(task)},
+		})
+	}
+	return map[string]interface{}{"events": events}
+}
+
+// stageOf maps a status to its stage, unknown statuses are closed
+func (s *Server) stageOf(kind string, status interface{}) string {
+	statuses := s.CaseStatuses
+	if kind == KindAlert {
+		statuses = s.AlertStatuses
+	}
+
+	for _, option := range statuses {
+		if option["value"] == status {
+			return option["stage"].(string)
+		}
+	}
+	return "Closed"
+}
+
+// readBody decodes a json or multipart request body
+func readBody(r *http.Request) (map[string]interface{}, map[string]interface{}, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			return nil, nil, badRequest("invalid multipart body: %s", err)
+		}
+
+		body := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(r.FormValue("_json")), &body); err != nil {
+			return nil, nil, badRequest("invalid _json field: %s", err)
+		}
+
+		var attachment map[string]interface{}
+		if file, header, err := r.FormFile("attachment"); err == nil {
+			defer file.Close()
+			content, _ := io.ReadAll(file)
+			attachment = map[string]interface{}{
+				"id":          fmt.Sprintf("%x", len(content)),
+				"name":        header.Filename,
+				"contentType": header.Header.Get("Content-Type"),
+				"size":        len(content),
+			}
+		}
+		return body, attachment, nil
+	}
+
+	content, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(content) == 0 {
+		return map[string]interface{}{}, nil, nil
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(content, &body); err != nil {
+		return nil, nil, badRequest("invalid json body: %s", err)
+	}
+	return body, nil, nil
+}
+
+// copyBody returns a deep copy of a decoded body, the handlers modify the original
+func copyBody(body map[string]interface{}) map[string]interface{} {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil
+	}
+	var copied map[string]interface{}
+	json.Unmarshal(data, &copied)
+	return copied
+}
+
+// writeError sends an error in thehive5 format
+func writeError(w http.ResponseWriter, err error) {
+	httpErr, ok := err.(*httpError)
+	if !ok {
+		httpErr = &httpError{http.StatusInternalServerError, "InternalError", err.Error()}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.status)
+	json.NewEncoder(w).Encode(map[string]string{"type": httpErr.errType, "message": httpErr.message})
+}
@@ -0,0 +1,69 @@
+package thehive5test_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	thehive5 "github.com/b401/goHive5"
+	"github.com/b401/goHive5/thehive5test"
+)
+
+func newClient(t *testing.T) (*thehive5test.Server, *thehive5.Hivedata) {
+	t.Helper()
+	srv := thehive5test.NewServer()
+	t.Cleanup(srv.Close)
+	return srv, srv.Hive()
+}
+
+func createCases(t *testing.T, hive *thehive5.Hivedata, severities ...string) []*thehive5.HiveCaseResponse {
+	t.Helper()
+	var cases []*thehive5.HiveCaseResponse
+	for i, severity := range severities {
+		created, err := hive.CreateCase(&thehive5.HiveCase{
+			Title:       fmt.Sprintf("case %d", i),
+			Description: "description",
+			Severity:    severity,
+		})
+		if err != nil {
+			t.Fatalf("CreateCase: %v", err)
+		}
+		cases = append(cases, created)
+	}
+	return cases
+}
+
+func TestCaseLifecycle(t *testing.T) {
+	_, hive := newClient(t)
+
+	created := createCases(t, hive, "high")[0]
+	if created.Number != 1 || created.Status != "New" {
+		t.Fatalf("unexpected case: number %d status %s", created.Number, created.Status)
+	}
+
+	got, err := hive.GetCase(created.Number)
+	if err != nil {
+		t.Fatalf("GetCase: %v", err)
+	}
+	if got.Id != created.Id || got.Title != "case 0" {
+		t.Fatalf("GetCase returned %s %q", got.Id, got.Title)
+	}
+
+	if err := hive.UpdateCase(created.Number, &thehive5.HiveUpdateCase{Title: "renamed", Status: "InProgress"}); err != nil {
+		t.Fatalf("UpdateCase: %v", err)
+	}
+	got, err = hive.GetCase(created.Number)
+	if err != nil {
+		t.Fatalf("GetCase: %v", err)
+	}
+	if got.Title != "renamed" || got.Status != "InProgress" || got.Stage != "InProgress" {
+		t.Fatalf("update not applied: %q %s %s", got.Title, got.Status, got.Stage)
+	}
+
+	if err := hive.DeleteCase(created.Number); err != nil {
+		t.Fatalf("DeleteCase: %v", err)
+	}
+	if _, err := hive.GetCase(created.Number); !errors.Is(err, thehive5.ErrNotFound) {
+		t.Fatalf("GetCase after delete returned %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,70 @@
+package thehive5test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Names of the cookies set by /api/v1/login
+const (
+	SessionCookie = "THEHIVE-SESSION"
+	XSRFCookie    = "THEHIVE-XSRF-TOKEN"
+)
+
+// ExpireSessions invalidates all sessions, the next request of a logged in client fails with 401
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions = map[string]string{}
+}
+
+// authenticated checks the apikey, the basic auth credentials or the session cookies of a request
+func (s *Server) authenticated(r *http.Request) bool {
+	if r.Header.Get("Authorization") == "Bearer "+s.APIKey {
+		return true
+	}
+	if user, password, ok := r.BasicAuth(); ok {
+		return user == s.User && password == s.Password
+	}
+
+	session, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return false
+	}
+	s.mu.Lock()
+	xsrf, ok := s.sessions[session.Value]
+	s.mu.Unlock()
+	// cookie based requests must mirror the xsrf token in a header
+	return ok && r.Header.Get("X-THEHIVE-XSRF-TOKEN") == xsrf
+}
+
+// login starts a new session if the user and password of the body match
+func (s *Server) login(w http.ResponseWriter, body map[string]interface{}) {
+	if body["user"] != s.User || body["password"] != s.Password {
+		writeError(w, &httpError{http.StatusUnauthorized, "AuthenticationError", "Authentication failure"})
+		return
+	}
+
+	s.mu.Lock()
+	s.lastSession++
+	session, xsrf := fmt.Sprintf("session-%d", s.lastSession), fmt.Sprintf("xsrf-%d", s.lastSession)
+	s.sessions[session] = xsrf
+	s.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{Name: SessionCookie, Value: session, Path: "/", HttpOnly: true})
+	http.SetCookie(w, &http.Cookie{Name: XSRFCookie, Value: xsrf, Path: "/"})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"login": s.User, "name": s.User})
+}
+
+// logout ends the session of the request
+func (s *Server) logout(w http.ResponseWriter, r *http.Request) {
+	if session, err := r.Cookie(SessionCookie); err == nil {
+		s.mu.Lock()
+		delete(s.sessions, session.Value)
+		s.mu.Unlock()
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
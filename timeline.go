@@ -5,6 +5,7 @@ https://www.strangebee.com/thehive/
 package thehive5
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -185,6 +186,11 @@ func (t *TimelineEvent) MarshalJSON() ([]byte, error) {
 
 // CreateTimelineEvent creates a new CustomEvent in a case
 func (hive *Hivedata) CreateTimelineEvent(caseId int, event *TimelineEvent) (*TimelineEventResponse, error) {
+	return hive.CreateTimelineEventContext(context.Background(), caseId, event)
+}
+
+// CreateTimelineEventContext is like CreateTimelineEvent but uses ctx for the request
+func (hive *Hivedata) CreateTimelineEventContext(ctx context.Context, caseId int, event *TimelineEvent) (*TimelineEventResponse, error) {
 	caseNumber := strconv.Itoa(caseId)
 	url, err := url.JoinPath(hive.Url, "/api/v1/case/", caseNumber, "/customEvent")
 	if err != nil {
@@ -196,7 +202,7 @@ func (hive *Hivedata) CreateTimelineEvent(caseId int, event *TimelineEvent) (*Ti
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, POST, jsonEvent)
+	ret, err := hive.webRequest(ctx, url, POST, jsonEvent)
 	if err != nil {
 		return nil, err
 	}
@@ -207,13 +213,18 @@ func (hive *Hivedata) CreateTimelineEvent(caseId int, event *TimelineEvent) (*Ti
 
 // GetTimeline returns all timeline objects from a case. This includes CustomEvents,Tasks and built-in events
 func (hive *Hivedata) GetTimeline(caseId int) ([]FullTimelineResponse, error) {
+	return hive.GetTimelineContext(context.Background(), caseId)
+}
+
+// GetTimelineContext is like GetTimeline but uses ctx for the request
+func (hive *Hivedata) GetTimelineContext(ctx context.Context, caseId int) ([]FullTimelineResponse, error) {
 	caseNumber := strconv.Itoa(caseId)
 	url, err := url.JoinPath(hive.Url, "/api/v1/case/", caseNumber, "/timeline")
 	if err != nil {
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, GET, nil)
+	ret, err := hive.webRequest(ctx, url, GET, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -235,7 +246,12 @@ func (hive *Hivedata) GetTimeline(caseId int) ([]FullTimelineResponse, error) {
 // GetTimelineEvent returns a single event
 // Returns err if no event was found
 func (hive *Hivedata) GetTimelineEvent(caseId int, eventId string) (*EventDetail, error) {
-	resp, err := hive.GetTimeline(caseId)
+	return hive.GetTimelineEventContext(context.Background(), caseId, eventId)
+}
+
+// GetTimelineEventContext is like GetTimelineEvent but uses ctx for the request
+func (hive *Hivedata) GetTimelineEventContext(ctx context.Context, caseId int, eventId string) (*EventDetail, error) {
+	resp, err := hive.GetTimelineContext(ctx, caseId)
 	if err != nil {
 		return nil, err
 	}
@@ -253,18 +269,28 @@ func (hive *Hivedata) GetTimelineEvent(caseId int, eventId string) (*EventDetail
 // DeleteTimelineEvent deletes a specific event
 // Returns err on failure
 func (hive *Hivedata) DeleteTimelineEvent(eventId string) error {
+	return hive.DeleteTimelineEventContext(context.Background(), eventId)
+}
+
+// DeleteTimelineEventContext is like DeleteTimelineEvent but uses ctx for the request
+func (hive *Hivedata) DeleteTimelineEventContext(ctx context.Context, eventId string) error {
 	url, err := url.JoinPath(hive.Url, "/api/v1/customEvent/", eventId)
 	if err != nil {
 		return err
 	}
 
-	_, err = hive.webRequest(url, DELETE, nil)
+	_, err = hive.webRequest(ctx, url, DELETE, nil)
 	return err
 }
 
 // UpdateTimelineEvent updates a specified event
 // returns err on failure
 func (hive *Hivedata) UpdateTimelineEvent(eventId string, event *TimelineEvent) error {
+	return hive.UpdateTimelineEventContext(context.Background(), eventId, event)
+}
+
+// UpdateTimelineEventContext is like UpdateTimelineEvent but uses ctx for the request
+func (hive *Hivedata) UpdateTimelineEventContext(ctx context.Context, eventId string, event *TimelineEvent) error {
 	url, err := url.JoinPath(hive.Url, "/api/v1/customEvent/", eventId)
 	if err != nil {
 		return err
@@ -275,6 +301,6 @@ func (hive *Hivedata) UpdateTimelineEvent(eventId string, event *TimelineEvent)
 		return err
 	}
 
-	_, err = hive.webRequest(url, PATCH, jsonEvent)
+	_, err = hive.webRequest(ctx, url, PATCH, jsonEvent)
 	return err
 }
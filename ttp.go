@@ -5,6 +5,7 @@ https://www.strangebee.com/thehive/
 package thehive5
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"strconv"
@@ -95,6 +96,11 @@ func (p *ProcedureResponse) UnmarshalJSON(data []byte) error {
 
 // AddAlertProcedure adds a procedure to an existing alert
 func (hive *Hivedata) AddAlertProcedure(alertId string, procedure *Procedure) (*ProcedureResponse, error) {
+	return hive.AddAlertProcedureContext(context.Background(), alertId, procedure)
+}
+
+// AddAlertProcedureContext is like AddAlertProcedure but uses ctx for the request
+func (hive *Hivedata) AddAlertProcedureContext(ctx context.Context, alertId string, procedure *Procedure) (*ProcedureResponse, error) {
 	url, err := url.JoinPath(hive.Url, "/api/v1/alert", alertId, "/procedurej")
 	if err != nil {
 		return nil, err
@@ -105,7 +111,7 @@ func (hive *Hivedata) AddAlertProcedure(alertId string, procedure *Procedure) (*
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, POST, jsonsearch)
+	ret, err := hive.webRequest(ctx, url, POST, jsonsearch)
 	if err != nil {
 		return nil, err
 	}
@@ -117,6 +123,11 @@ func (hive *Hivedata) AddAlertProcedure(alertId string, procedure *Procedure) (*
 
 // AddCaseProcedure adds a procedure to an existing case
 func (hive *Hivedata) AddCaseProcedure(caseId int, procedure *Procedure) (*ProcedureResponse, error) {
+	return hive.AddCaseProcedureContext(context.Background(), caseId, procedure)
+}
+
+// AddCaseProcedureContext is like AddCaseProcedure but uses ctx for the request
+func (hive *Hivedata) AddCaseProcedureContext(ctx context.Context, caseId int, procedure *Procedure) (*ProcedureResponse, error) {
 	caseNumber := strconv.Itoa(caseId)
 	url, err := url.JoinPath(hive.Url, "/api/v1/case/", caseNumber, "/procedurej")
 	if err != nil {
@@ -128,7 +139,7 @@ func (hive *Hivedata) AddCaseProcedure(caseId int, procedure *Procedure) (*Proce
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, POST, jsonsearch)
+	ret, err := hive.webRequest(ctx, url, POST, jsonsearch)
 	if err != nil {
 		return nil, err
 	}
@@ -5,6 +5,7 @@ https://www.strangebee.com/thehive/
 package thehive5
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"time"
@@ -106,13 +107,13 @@ func (u *UserResponse) UnmarshalJSON(data []byte) error {
 }
 
 // executeCommentSearchQuery is a helper function to do query related searches
-func (hive *Hivedata) executeUserSearchQuery(query []byte) ([]UserResponse, error) {
+func (hive *Hivedata) executeUserSearchQuery(ctx context.Context, query []byte) ([]UserResponse, error) {
 	url, err := url.JoinPath(hive.Url, "/api/v1/query")
 	if err != nil {
 		return nil, err
 	}
 
-	ret, err := hive.webRequest(url, POST, query)
+	ret, err := hive.webRequest(ctx, url, POST, query)
 	if err != nil {
 		return nil, err
 	}
@@ -124,6 +125,11 @@ func (hive *Hivedata) executeUserSearchQuery(query []byte) ([]UserResponse, erro
 
 // GetVisibleUsers returns all users that are visible to the service
 func (hive *Hivedata) GetVisibleUsers() ([]UserResponse, error) {
+	return hive.GetVisibleUsersContext(context.Background())
+}
+
+// GetVisibleUsersContext is like GetVisibleUsers but uses ctx for the request
+func (hive *Hivedata) GetVisibleUsersContext(ctx context.Context) ([]UserResponse, error) {
 
 	query, err := hive.createSearchQuery(
 		SearchQuery{Name: "listVisibleUsers"},
@@ -132,5 +138,5 @@ func (hive *Hivedata) GetVisibleUsers() ([]UserResponse, error) {
 		return nil, err
 	}
 
-	return hive.executeUserSearchQuery(query)
+	return hive.executeUserSearchQuery(ctx, query)
 }
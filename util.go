@@ -6,7 +6,9 @@ package thehive5
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -26,49 +28,16 @@ type Attachment struct {
 
 // webRequest is an internal helper to build the right webrequest structure
 // it adds additional headers & returns the json body
-// Unknown status codes get returned as error
-func (hive *Hivedata) webRequest(url string, m method, body []byte) ([]byte, error) {
-	b := bytes.NewReader(body)
-
-	req, err := http.NewRequest(string(m), url, b)
-	if err != nil {
-		return nil, err
-	}
-
-	// prepare headers
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Accept", "application/json")
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", hive.Apikey))
-	resp, err := hive.Client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	responseBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	// Check thehive response code and determine if we need to return an error
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		var errorResp ApiErrorResponse
-		err = json.Unmarshal(responseBody, &errorResp)
-		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal api http error")
-		}
-
-		return nil, fmt.Errorf("API error: %v", errorResp)
-
-	}
-
-	return responseBody, nil
+// Unknown status codes get returned as *APIError
+// The request is bound to ctx so callers can cancel it or set a deadline
+func (hive *Hivedata) webRequest(ctx context.Context, url string, m method, body []byte) ([]byte, error) {
+	return hive.do(ctx, url, m, body, "application/json")
 }
 
 // webRequestMultiPart is an internal helper to build the right webrequest structure for uploads
 // it adds additional headers & returns the json body
-// Unknown status codes get returned as error
-func (hive *Hivedata) webRequestMultiPart(url string, m method, body []byte, file *os.File) ([]byte, error) {
+// Unknown status codes get returned as *APIError
+func (hive *Hivedata) webRequestMultiPart(ctx context.Context, url string, m method, body []byte, file *os.File) ([]byte, error) {
 	var b bytes.Buffer
 	writer := multipart.NewWriter(&b)
 
@@ -98,39 +67,110 @@ func (hive *Hivedata) webRequestMultiPart(url string, m method, body []byte, fil
 		return nil, err
 	}
 
-	req, err := http.NewRequest(string(m), url, &b)
+	// the encoded form is kept in memory so it can be replayed on retries
+	return hive.do(ctx, url, m, b.Bytes(), writer.FormDataContentType())
+}
+
+// do sends a request to thehive5 and retries it according to the RetryPolicy of the client
+func (hive *Hivedata) do(ctx context.Context, url string, m method, body []byte, contentType string) ([]byte, error) {
+	reauthenticated := false
+	for attempt := 1; ; attempt++ {
+		responseBody, req, err := hive.doOnce(ctx, url, m, body, contentType)
+		if err == nil {
+			return responseBody, nil
+		}
+
+		// expired sessions get renewed once without counting as an attempt
+		var apiErr *APIError
+		if hive.Auth != nil && req != nil && !reauthenticated && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+			reauthenticated = true
+			resend, authErr := hive.Auth.Reauthenticate(ctx, hive, req)
+			if authErr != nil {
+				return nil, authErr
+			}
+			if resend {
+				attempt--
+				continue
+			}
+		}
+
+		wait, retry := hive.Retry.next(ctx, m, url, attempt, err)
+		if !retry {
+			return nil, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			// both the cancellation and the last failure can be checked with errors.Is/As
+			return nil, fmt.Errorf("%w (last attempt: %w)", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// doOnce executes a single attempt of a request
+// The sent request is returned to renew the authentication if it was rejected, nil if it wasn't sent
+func (hive *Hivedata) doOnce(ctx context.Context, url string, m method, body []byte, contentType string) ([]byte, *http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, string(m), url, bytes.NewReader(body))
 	if err != nil {
-		return nil, err
+		return nil, req, err
 	}
 
 	// prepare headers
-	req.Header.Add("Content-Type", writer.FormDataContentType())
+	req.Header.Add("Content-Type", contentType)
 	req.Header.Add("Accept", "application/json")
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", hive.Apikey))
+	// the authentication happens before acquiring the limiter as a login takes a slot itself
+	if hive.Auth != nil {
+		if err := hive.Auth.Authenticate(ctx, hive, req); err != nil {
+			// the request wasn't sent, a failed login isn't renewed
+			return nil, nil, err
+		}
+	} else {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", hive.Apikey))
+	}
+	hive.setHeaders(req)
+
+	release, err := hive.Limiter.acquire(ctx)
+	if err != nil {
+		return nil, req, err
+	}
+	defer release()
+
 	resp, err := hive.Client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, req, err
 	}
 	defer resp.Body.Close()
 
 	responseBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, req, err
 	}
 
 	// Check thehive response code and determine if we need to return an error
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		var errorResp ApiErrorResponse
-		err = json.Unmarshal(responseBody, &errorResp)
-		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal api http error")
-		}
+		return nil, req, newAPIError(req, resp, responseBody)
+	}
 
-		return nil, fmt.Errorf("API error: %v", errorResp)
+	return responseBody, req, nil
+}
 
+// setHeaders adds the User-Agent, the custom headers and the organisation of the client to req
+func (hive *Hivedata) setHeaders(req *http.Request) {
+	if len(hive.UserAgent) != 0 {
+		req.Header.Set("User-Agent", hive.UserAgent)
+	}
+	for key, values := range hive.Headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	// the organisation is set last, it replaces an X-Organisation header added with WithHeader
+	if len(hive.Organisation) != 0 {
+		req.Header.Set("X-Organisation", hive.Organisation)
 	}
-
-	return responseBody, nil
 }
 
 // Helper function to build a search query for the /query endpoint
@@ -234,6 +274,8 @@ func (s *Severity) FromString(v string) error {
 		value = SeverityHigh
 	case "critical":
 		value = SeverityCritical
+	default:
+		return fmt.Errorf("unknown severity value: %s. Allowed: low,medium,high,critical", v)
 	}
 
 	*s = value
@@ -338,7 +380,7 @@ func detectCustomFieldType(s interface{}) (*string, error) {
 	// standard tests
 	case string:
 		str = "string"
-	case int:
+	case int, int32, int64:
 		str = "integer"
 	case bool:
 		str = "boolean"
@@ -346,7 +388,7 @@ func detectCustomFieldType(s interface{}) (*string, error) {
 		str = "float"
 	case float64:
 		str = "float"
-	case time.Time:
+	case time.Time, *time.Time:
 		str = "date"
 	}
 
@@ -0,0 +1,371 @@
+/*
+thehive5 implements functionality to interact with the most recent version of thehive.
+https://www.strangebee.com/thehive/
+*/
+package thehive5
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// taskStatuses are the statuses a task can have on thehive5
+var taskStatuses = []string{"Waiting", "InProgress", "Completed", "Cancel"}
+
+// A FieldError describes a single invalid attribute, Field uses the json names (e.g. tasks[0].title)
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors lists every problem found by Validate
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	problems := make([]string, len(v))
+	for i, err := range v {
+		problems[i] = err.Error()
+	}
+	return "invalid request: " + strings.Join(problems, "; ")
+}
+
+// add records a problem of field
+func (v *ValidationErrors) add(field string, format string, args ...interface{}) {
+	*v = append(*v, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns nil if no problem was found, so the result can be returned as error
+func (v ValidationErrors) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
+// Metadata contains the configuration of a thehive5 instance used by ValidateWith
+// It is loaded once with LoadMetadata and can be reused for any number of validations
+type Metadata struct {
+	ObservableTypes []ObservableTypeResponse
+	CustomFields    []CustomFieldDefinitionResponse
+	CaseStatuses    []CaseStatusResponse
+	AlertStatuses   []AlertStatusResponse
+	LoadedAt        time.Time
+}
+
+// LoadMetadata fetches the observable types, custom field definitions and statuses of thehive5
+func (hive *Hivedata) LoadMetadata() (*Metadata, error) {
+	return hive.LoadMetadataContext(context.Background())
+}
+
+// LoadMetadataContext is like LoadMetadata but uses ctx for the requests
+func (hive *Hivedata) LoadMetadataContext(ctx context.Context) (*Metadata, error) {
+	observableTypes, err := hive.GetObservableTypesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	customFields, err := hive.ListCustomFieldDefinitionsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	caseStatuses, err := hive.GetCaseStatusOptionsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	alertStatuses, err := hive.GetAlertStatusOptionsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Metadata{
+		ObservableTypes: observableTypes,
+		CustomFields:    customFields,
+		CaseStatuses:    caseStatuses,
+		AlertStatuses:   alertStatuses,
+		LoadedAt:        time.Now(),
+	}, nil
+}
+
+// Validate checks the case without contacting thehive5 and returns ValidationErrors listing every problem
+func (hc *HiveCase) Validate() error {
+	return hc.ValidateWith(nil)
+}
+
+// ValidateWith is like Validate but also checks statuses and custom fields against meta
+func (hc *HiveCase) ValidateWith(meta *Metadata) error {
+	var errs ValidationErrors
+	required(&errs, "title", hc.Title)
+	required(&errs, "description", hc.Description)
+	validateLevels(&errs, hc.Severity, hc.Tlp, hc.Pap)
+	validateDates(&errs, "endDate", hc.StartDate, hc.EndDate)
+	if meta != nil && len(hc.Status) != 0 {
+		validateStatus(&errs, hc.Status, meta.CaseStatuses)
+	}
+	if hc.CustomFields != nil {
+		validateCustomFields(&errs, *hc.CustomFields, meta)
+	}
+	if hc.Tasks != nil {
+		validateTasks(&errs, *hc.Tasks)
+	}
+	return errs.err()
+}
+
+// Validate checks the update without contacting thehive5 and returns ValidationErrors listing every problem
+func (hu *HiveUpdateCase) Validate() error {
+	return hu.ValidateWith(nil)
+}
+
+// ValidateWith is like Validate but also checks statuses and custom fields against meta
+func (hu *HiveUpdateCase) ValidateWith(meta *Metadata) error {
+	var errs ValidationErrors
+	validateLevels(&errs, hu.Severity, hu.Tlp, hu.Pap)
+	validateDates(&errs, "endDate", hu.StartDate, hu.EndDate)
+	if hu.ImpactStatus != nil && len(*hu.ImpactStatus) != 0 {
+		if _, err := impactStatus(*hu.ImpactStatus); err != nil {
+			errs.add("impactStatus", "unknown impact value %q. Allowed: WithImpact,NoImpact,NotApplicable", *hu.ImpactStatus)
+		}
+	}
+	if meta != nil && len(hu.Status) != 0 {
+		validateStatus(&errs, hu.Status, meta.CaseStatuses)
+	}
+	if hu.CustomFields != nil {
+		validateCustomFields(&errs, *hu.CustomFields, meta)
+	}
+	if hu.Tasks != nil {
+		validateTasks(&errs, *hu.Tasks)
+	}
+	return errs.err()
+}
+
+// Validate checks the alert without contacting thehive5 and returns ValidationErrors listing every problem
+func (ha *HiveAlert) Validate() error {
+	return ha.ValidateWith(nil)
+}
+
+// ValidateWith is like Validate but also checks statuses, custom fields and observable types against meta
+func (ha *HiveAlert) ValidateWith(meta *Metadata) error {
+	var errs ValidationErrors
+	required(&errs, "source", ha.Source)
+	required(&errs, "sourceRef", ha.SourceRef)
+	required(&errs, "title", ha.Title)
+	required(&errs, "description", ha.Description)
+	validateLevels(&errs, ha.Severity, ha.Tlp, ha.Pap)
+	if meta != nil && len(ha.Status) != 0 {
+		validateStatus(&errs, ha.Status, meta.AlertStatuses)
+	}
+	if ha.CustomFields != nil {
+		validateCustomFields(&errs, *ha.CustomFields, meta)
+	}
+	if ha.Observables != nil {
+		for i := range *ha.Observables {
+			(*ha.Observables)[i].validate(&errs, fmt.Sprintf("observables[%d].", i), meta)
+		}
+	}
+	return errs.err()
+}
+
+// Validate checks the observable without contacting thehive5 and returns ValidationErrors listing every problem
+func (o *Observable) Validate() error {
+	return o.ValidateWith(nil)
+}
+
+// ValidateWith is like Validate but also checks the data type against meta
+func (o *Observable) ValidateWith(meta *Metadata) error {
+	var errs ValidationErrors
+	o.validate(&errs, "", meta)
+	return errs.err()
+}
+
+// validate adds the problems of the observable with every field prefixed
+func (o *Observable) validate(errs *ValidationErrors, prefix string, meta *Metadata) {
+	required(errs, prefix+"dataType", o.DataType)
+	// the data of file observables is sent as attachment
+	if o.DataType != "file" {
+		required(errs, prefix+"data", o.Data)
+	}
+	if len(o.Tlp) != 0 {
+		var tlp Tlp
+		if err := tlp.FromString(o.Tlp); err != nil {
+			errs.add(prefix+"tlp", "%v", err)
+		}
+	}
+	if len(o.Pap) != 0 {
+		var pap Pap
+		if err := pap.FromString(o.Pap); err != nil {
+			errs.add(prefix+"pap", "%v", err)
+		}
+	}
+	if meta != nil && len(o.DataType) != 0 {
+		known := slices.ContainsFunc(meta.ObservableTypes, func(t ObservableTypeResponse) bool {
+			return t.Name == o.DataType
+		})
+		if !known {
+			errs.add(prefix+"dataType", "unknown observable type %q", o.DataType)
+		}
+	}
+}
+
+// Validate checks the task without contacting thehive5 and returns ValidationErrors listing every problem
+func (ct *CaseTask) Validate() error {
+	var errs ValidationErrors
+	ct.validate(&errs, "")
+	return errs.err()
+}
+
+// validate adds the problems of the task with every field prefixed
+func (ct *CaseTask) validate(errs *ValidationErrors, prefix string) {
+	required(errs, prefix+"title", ct.Title)
+	if len(ct.Status) != 0 && !slices.Contains(taskStatuses, ct.Status) {
+		errs.add(prefix+"status", "unknown task status %q. Allowed: %s", ct.Status, strings.Join(taskStatuses, ","))
+	}
+	validateDates(errs, prefix+"endDate", ct.StartDate, ct.EndDate)
+}
+
+// validateTasks adds the problems of all tasks
+func validateTasks(errs *ValidationErrors, tasks []CaseTask) {
+	for i := range tasks {
+		tasks[i].validate(errs, fmt.Sprintf("tasks[%d].", i))
+	}
+}
+
+// required adds a problem if value is empty
+func required(errs *ValidationErrors, field string, value string) {
+	if len(strings.TrimSpace(value)) == 0 {
+		errs.add(field, "is required")
+	}
+}
+
+// validateLevels checks the severity, tlp and pap strings if they are set
+func validateLevels(errs *ValidationErrors, severity string, tlp string, pap string) {
+	if len(severity) != 0 {
+		var sev Severity
+		if err := sev.FromString(severity); err != nil {
+			errs.add("severity", "%v", err)
+		}
+	}
+	if len(tlp) != 0 {
+		var t Tlp
+		if err := t.FromString(tlp); err != nil {
+			errs.add("tlp", "%v", err)
+		}
+	}
+	if len(pap) != 0 {
+		var p Pap
+		if err := p.FromString(pap); err != nil {
+			errs.add("pap", "%v", err)
+		}
+	}
+}
+
+// validateDates adds a problem if end is before start
+func validateDates(errs *ValidationErrors, field string, start time.Time, end time.Time) {
+	if !start.IsZero() && !end.IsZero() && end.Before(start) {
+		errs.add(field, "is before startDate")
+	}
+}
+
+// validateStatus adds a problem if status isn't one of the configured statuses
+func validateStatus(errs *ValidationErrors, status string, statuses []CaseStatusResponse) {
+	var valid []string
+	for _, option := range statuses {
+		if option.Value == status {
+			return
+		}
+		valid = append(valid, option.Value)
+	}
+	errs.add("status", "unknown status %q. Allowed: %s", status, strings.Join(valid, ","))
+}
+
+// validateCustomFields checks that every custom field can be sent and,
+// with meta, that it is defined on thehive5 and its value matches the definition
+func validateCustomFields(errs *ValidationErrors, fields []CustomField, meta *Metadata) {
+	for _, field := range fields {
+		name := fmt.Sprintf("customFields[%s]", strings.ToLower(field.Name))
+		if len(field.Name) == 0 {
+			errs.add(name, "name is required")
+			continue
+		}
+
+		// the same conversion as CustomField.MarshalJSON
+		fieldType := field.Type
+		if len(fieldType) == 0 {
+			detected, err := detectCustomFieldType(field.Value)
+			if err != nil {
+				errs.add(name, "%v", err)
+				continue
+			}
+			fieldType = *detected
+		}
+		if _, err := customFieldValue(fieldType, field.Value); err != nil {
+			errs.add(name, "%v", err)
+			continue
+		}
+		if meta == nil || field.Value == nil {
+			continue
+		}
+
+		index := slices.IndexFunc(meta.CustomFields, func(d CustomFieldDefinitionResponse) bool {
+			return d.Name == strings.ToLower(field.Name)
+		})
+		if index == -1 {
+			errs.add(name, "custom field is not defined on thehive5")
+			continue
+		}
+		definition := meta.CustomFields[index]
+
+		if len(field.Type) != 0 && field.Type != definition.FieldType {
+			errs.add(name, "type %s doesn't match the defined type %s", field.Type, definition.FieldType)
+			continue
+		}
+		if !customFieldValueMatches(definition.FieldType, field.Value) {
+			errs.add(name, "value %v is not a valid %s", field.Value, definition.FieldType)
+			continue
+		}
+		if len(definition.Options) != 0 && !customFieldOptionsContain(definition, field.Value) {
+			errs.add(name, "value %v is not one of the options %v", field.Value, definition.Options)
+		}
+	}
+}
+
+// customFieldValueMatches reports if value can be stored in a custom field of fieldType
+func customFieldValueMatches(fieldType string, value interface{}) bool {
+	detected, err := detectCustomFieldType(value)
+	if err != nil {
+		return false
+	}
+
+	switch fieldType {
+	case "string":
+		return *detected == "string" || *detected == "url"
+	case "integer":
+		// integers read back from thehive5 are float64
+		if f, ok := value.(float64); ok {
+			return f == math.Trunc(f)
+		}
+		return *detected == "integer"
+	case "float":
+		return *detected == "float" || *detected == "integer"
+	case "date":
+		return *detected == "date" || *detected == "integer"
+	}
+	return *detected == fieldType
+}
+
+// customFieldOptionsContain compares the value with the options as they would be sent to thehive5
+func customFieldOptionsContain(definition CustomFieldDefinitionResponse, value interface{}) bool {
+	field := CustomField{Type: definition.FieldType, Value: value}
+	for _, option := range definition.Options {
+		equal, err := sameCustomFieldValue(field, CustomField{Type: definition.FieldType, Value: option})
+		if err == nil && equal {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package thehive5_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	thehive5 "github.com/b401/goHive5"
+)
+
+// invalidFields returns the fields reported by a ValidationErrors, nil if err is nil
+func invalidFields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var errs thehive5.ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("%v is not a ValidationErrors", err)
+	}
+	var fields []string
+	for _, fieldErr := range errs {
+		fields = append(fields, fieldErr.Field)
+	}
+	return fields
+}
+
+func checkFields(t *testing.T, name string, err error, want ...string) {
+	t.Helper()
+	if got := invalidFields(t, err); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("%s reported %v (%v), want %v", name, got, err, want)
+	}
+}
+
+// metadata returns the configuration of an instance with a custom case status
+func metadata() *thehive5.Metadata {
+	return &thehive5.Metadata{
+		ObservableTypes: []thehive5.ObservableTypeResponse{{Name: "ip"}, {Name: "domain"}, {Name: "file", IsAttachment: true}},
+		CaseStatuses:    []thehive5.CaseStatusResponse{{Value: "New"}, {Value: "InProgress"}, {Value: "Escalated"}, {Value: "TruePositive"}},
+		AlertStatuses:   []thehive5.AlertStatusResponse{{Value: "New"}, {Value: "Ignored"}},
+	}
+}
+
+func TestValidateRequiredFields(t *testing.T) {
+	checkFields(t, "HiveCase", (&thehive5.HiveCase{}).Validate(), "title", "description")
+	checkFields(t, "HiveCase with blank title", (&thehive5.HiveCase{Title: "  ", Description: "case"}).Validate(), "title")
+	checkFields(t, "HiveCase", (&thehive5.HiveCase{Title: "case", Description: "case"}).Validate())
+	checkFields(t, "HiveAlert", (&thehive5.HiveAlert{Type: "external"}).Validate(), "source", "sourceRef", "title", "description")
+	checkFields(t, "HiveUpdateCase", (&thehive5.HiveUpdateCase{}).Validate())
+	checkFields(t, "CaseTask", (&thehive5.CaseTask{}).Validate(), "title")
+
+	checkFields(t, "Observable", (&thehive5.Observable{}).Validate(), "dataType", "data")
+	// the data of a file is sent as attachment
+	checkFields(t, "file Observable", (&thehive5.Observable{DataType: "file"}).Validate())
+}
+
+func TestValidateLevels(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		input thehive5.HiveCase
+		want  []string
+	}{
+		{"valid", thehive5.HiveCase{Severity: "High", Tlp: "amber", Pap: "red"}, nil},
+		{"severity", thehive5.HiveCase{Severity: "urgent"}, []string{"severity"}},
+		{"tlp", thehive5.HiveCase{Tlp: "purple"}, []string{"tlp"}},
+		{"pap", thehive5.HiveCase{Pap: "black"}, []string{"pap"}},
+		{"all", thehive5.HiveCase{Severity: "5", Tlp: "x", Pap: "y"}, []string{"severity", "tlp", "pap"}},
+	} {
+		hc := tt.input
+		hc.Title, hc.Description = "case", "case"
+		checkFields(t, tt.name, hc.Validate(), tt.want...)
+	}
+
+	checkFields(t, "HiveUpdateCase", (&thehive5.HiveUpdateCase{Severity: "urgent", Tlp: "green"}).Validate(), "severity")
+	checkFields(t, "HiveAlert", (&thehive5.HiveAlert{Source: "test", SourceRef: "1", Title: "alert", Description: "alert", Pap: "black"}).Validate(), "pap")
+	checkFields(t, "Observable", (&thehive5.Observable{DataType: "ip", Data: "10.0.0.1", Tlp: "purple", Pap: "black"}).Validate(), "tlp", "pap")
+}
+
+func TestValidateDates(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	checkFields(t, "end before start", (&thehive5.HiveCase{Title: "case", Description: "case", StartDate: start, EndDate: start.Add(-time.Hour)}).Validate(), "endDate")
+	checkFields(t, "end after start", (&thehive5.HiveCase{Title: "case", Description: "case", StartDate: start, EndDate: start.Add(time.Hour)}).Validate())
+	checkFields(t, "end without start", (&thehive5.HiveCase{Title: "case", Description: "case", EndDate: start}).Validate())
+	checkFields(t, "update", (&thehive5.HiveUpdateCase{StartDate: start, EndDate: start.Add(-time.Second)}).Validate(), "endDate")
+	checkFields(t, "task", (&thehive5.CaseTask{Title: "task", StartDate: start, EndDate: start.Add(-time.Minute)}).Validate(), "endDate")
+}
+
+func TestValidateTaskStatus(t *testing.T) {
+	for _, status := range []string{"", "Waiting", "InProgress", "Completed", "Cancel"} {
+		checkFields(t, "task "+status, (&thehive5.CaseTask{Title: "task", Status: status}).Validate())
+	}
+	checkFields(t, "task Done", (&thehive5.CaseTask{Title: "task", Status: "Done"}).Validate(), "status")
+	checkFields(t, "task completed", (&thehive5.CaseTask{Title: "task", Status: "completed"}).Validate(), "status")
+
+	// the tasks of a case are reported with their index
+	tasks := []thehive5.CaseTask{{Title: "contain"}, {Status: "Done"}}
+	checkFields(t, "case tasks", (&thehive5.HiveCase{Title: "case", Description: "case", Tasks: &tasks}).Validate(), "tasks[1].title", "tasks[1].status")
+}
+
+func TestValidateObservableTypes(t *testing.T) {
+	meta := metadata()
+
+	checkFields(t, "known type", (&thehive5.Observable{DataType: "domain", Data: "evil.example"}).ValidateWith(meta))
+	checkFields(t, "unknown type", (&thehive5.Observable{DataType: "hostname", Data: "evil"}).ValidateWith(meta), "dataType")
+	// without metadata the type isn't checked
+	checkFields(t, "unknown type without metadata", (&thehive5.Observable{DataType: "hostname", Data: "evil"}).Validate())
+
+	observables := []thehive5.Observable{{DataType: "ip", Data: "10.0.0.1"}, {DataType: "mail", Data: "a@evil.example"}, {DataType: "ip"}}
+	alert := &thehive5.HiveAlert{Source: "test", SourceRef: "1", Title: "alert", Description: "alert", Observables: &observables}
+	checkFields(t, "alert observables", alert.ValidateWith(meta), "observables[1].dataType", "observables[2].data")
+}
+
+func TestValidateStatuses(t *testing.T) {
+	meta := metadata()
+
+	checkFields(t, "custom case status", (&thehive5.HiveCase{Title: "case", Description: "case", Status: "Escalated"}).ValidateWith(meta))
+	checkFields(t, "unknown case status", (&thehive5.HiveCase{Title: "case", Description: "case", Status: "Escalate"}).ValidateWith(meta), "status")
+	checkFields(t, "unknown update status", (&thehive5.HiveUpdateCase{Status: "Solved"}).ValidateWith(meta), "status")
+	checkFields(t, "alert status", (&thehive5.HiveAlert{Source: "test", SourceRef: "1", Title: "alert", Description: "alert", Status: "Ignored"}).ValidateWith(meta))
+	// case statuses aren't valid for alerts
+	checkFields(t, "case status on an alert", (&thehive5.HiveAlert{Source: "test", SourceRef: "1", Title: "alert", Description: "alert", Status: "Escalated"}).ValidateWith(meta), "status")
+	// without metadata the status isn't checked
+	checkFields(t, "unknown status without metadata", (&thehive5.HiveUpdateCase{Status: "Solved"}).Validate())
+
+	err := (&thehive5.HiveUpdateCase{Status: "Solved"}).ValidateWith(meta)
+	if !strings.Contains(err.Error(), "New,InProgress,Escalated,TruePositive") {
+		t.Fatalf("the error doesn't list the valid statuses: %v", err)
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	tasks := []thehive5.CaseTask{{Title: "task", Status: "Done"}}
+	fields := []thehive5.CustomField{{Value: "unnamed"}}
+	hc := &thehive5.HiveCase{
+		Severity:     "urgent",
+		Status:       "Solved",
+		StartDate:    time.Now(),
+		EndDate:      time.Now().Add(-time.Hour),
+		Tasks:        &tasks,
+		CustomFields: &fields,
+	}
+
+	err := hc.ValidateWith(metadata())
+	checkFields(t, "HiveCase", err, "title", "description", "severity", "endDate", "status", "customFields[]", "tasks[0].status")
+	if !strings.HasPrefix(err.Error(), "invalid request: title: is required; description: is required; severity: ") {
+		t.Fatalf("Error() = %q", err.Error())
+	}
+}